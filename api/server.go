@@ -42,6 +42,7 @@ import (
 /* global variables */
 var kvStores *kvstore.KVStores
 var dbStats *kvstore.KStats
+var searcher *search.Searcher
 var tmpFolder = "/tmp/"
 var nbOfThreads = 0
 
@@ -74,6 +75,8 @@ func NewServer(dbPath string, portNumber int, tableLoadingMode options.FileLoadi
 	dbStats = &kvstore.KStats{}
 	proto.Unmarshal(dbStatsByte, dbStats)
 
+	searcher = search.NewSearcher(kvStores, *dbStats, nbOfThreads)
+
 	elapsed := time.Since(startTime)
 	elapsed = elapsed.Round(time.Second)
 	out := fmt.Sprintf("done [%s]\n", duration.FmtDuration(elapsed))
@@ -123,6 +126,7 @@ func APIRoutes(r chi.Router, path string, kvStores *kvstore.KVStores) {
 		r.Post("/search/protein", searchProtein)
 		r.Post("/search/fastq", searchFastq)
 		r.Post("/search/nucleotide", searchNucleotide)
+		r.Post("/search/matrix", searchMatrix)
 		r.Get("/dbinfo", func(w http.ResponseWriter, r *http.Request) {
 			b, err := json.Marshal(dbStats)
 			if err != nil {
@@ -137,37 +141,25 @@ func APIRoutes(r chi.Router, path string, kvStores *kvstore.KVStores) {
 
 func searchFastq(w http.ResponseWriter, r *http.Request) {
 
-	searchOptions := search.SearchOptions{
-		File:             "",
-		InputType:        r.FormValue("type"),
-		GeneticCode:      11,
-		SequenceType:     search.READS,
-		OutFormat:        "tsv",
-		MaxResults:       10,
-		ExtractPositions: false,
-	}
+	searchOptions := search.DefaultSearchOptions()
+	searchOptions.InputType = r.FormValue("type")
+	searchOptions.SequenceType = search.READS
 
 	err := parseSearchOptions(&searchOptions, w, r)
 	if err != nil {
 		w.WriteHeader(400)
 		fmt.Fprintln(w, err.Error())
 	} else {
-		search.NewSearchResult(searchOptions, *dbStats, kvStores, nbOfThreads, w, r)
+		search.NewSearchResult(searchOptions, searcher, w, r)
 	}
 
 }
 
 func searchNucleotide(w http.ResponseWriter, r *http.Request) {
 
-	searchOptions := search.SearchOptions{
-		File:             "",
-		InputType:        r.FormValue("type"),
-		GeneticCode:      11,
-		SequenceType:     search.NUCLEOTIDE,
-		OutFormat:        "tsv",
-		MaxResults:       10,
-		ExtractPositions: false,
-	}
+	searchOptions := search.DefaultSearchOptions()
+	searchOptions.InputType = r.FormValue("type")
+	searchOptions.SequenceType = search.NUCLEOTIDE
 
 	err := parseSearchOptions(&searchOptions, w, r)
 
@@ -175,22 +167,16 @@ func searchNucleotide(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(400)
 		fmt.Fprintln(w, err.Error())
 	} else {
-		search.NewSearchResult(searchOptions, *dbStats, kvStores, nbOfThreads, w, r)
+		search.NewSearchResult(searchOptions, searcher, w, r)
 	}
 
 }
 
 func searchProtein(w http.ResponseWriter, r *http.Request) {
 
-	searchOptions := search.SearchOptions{
-		File:             "",
-		InputType:        r.FormValue("type"),
-		GeneticCode:      11, // not needed
-		SequenceType:     search.PROTEIN,
-		OutFormat:        "tsv",
-		MaxResults:       10,
-		ExtractPositions: false,
-	}
+	searchOptions := search.DefaultSearchOptions()
+	searchOptions.InputType = r.FormValue("type")
+	searchOptions.SequenceType = search.PROTEIN
 
 	err := parseSearchOptions(&searchOptions, w, r)
 
@@ -198,11 +184,113 @@ func searchProtein(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(400)
 		fmt.Fprintln(w, err.Error())
 	} else {
-		search.NewSearchResult(searchOptions, *dbStats, kvStores, nbOfThreads, w, r)
+		search.NewSearchResult(searchOptions, searcher, w, r)
 	}
 
 }
 
+func searchMatrix(w http.ResponseWriter, r *http.Request) {
+
+	searchOptions := search.DefaultSearchOptions()
+	searchOptions.InputType = r.FormValue("type")
+	searchOptions.SequenceType = search.READS
+
+	feature := search.MATRIX_PROTEIN
+	if r.FormValue("feature") != "" {
+		feature = r.FormValue("feature")
+	}
+
+	samples, err := parseMatrixSamples(r)
+	if err == nil && !search.ValidMatrixFeature(feature) {
+		err = errors.New("Matrix feature needs to be protein, taxon, " + strings.Join(search.SummaryFeatures, ", "))
+	}
+	if err == nil {
+		err = parseSearchParameters(&searchOptions, r)
+	}
+
+	if err != nil {
+		if searchOptions.InputType != "path" {
+			for _, sample := range samples {
+				os.Remove(sample.File)
+			}
+		}
+		w.WriteHeader(400)
+		fmt.Fprintln(w, err.Error())
+	} else {
+		search.NewMatrixResult(searchOptions, samples, feature, searcher, w, r)
+	}
+
+}
+
+// parseMatrixSamples returns the samples of a matrix search : the read files
+// (file fields, uploaded or path) with their names (sample fields, in the same
+// order) or their file names if no sample names are given
+func parseMatrixSamples(r *http.Request) ([]search.Sample, error) {
+
+	var files []string
+	var names []string
+
+	switch r.FormValue("type") {
+	case "file":
+		var err error
+		files, names, err = filesUploadHandler(r, "fastq")
+		if err != nil {
+			return nil, err
+		}
+	case "path":
+		files = r.Form["file"]
+		for _, f := range files {
+			if _, err := os.Stat(f); os.IsNotExist(err) {
+				return nil, err
+			}
+			names = append(names, f)
+		}
+	default:
+		return nil, errors.New("Need request type (file|path)")
+	}
+
+	samples := []search.Sample{}
+	for i, f := range files {
+		samples = append(samples, search.Sample{Name: sampleName(names[i]), File: f})
+	}
+
+	if len(samples) == 0 {
+		return samples, errors.New("Need at least one sample file")
+	}
+
+	if len(r.Form["sample"]) > 0 {
+		if len(r.Form["sample"]) != len(samples) {
+			return samples, errors.New("Need one sample name per sample file")
+		}
+		for i := range samples {
+			samples[i].Name = r.Form["sample"][i]
+		}
+	}
+
+	sampleNames := map[string]bool{}
+	for _, sample := range samples {
+		if sample.Name == "" || strings.ContainsAny(sample.Name, "\t\n") {
+			return samples, errors.New("Sample names cannot be empty or contain tabs")
+		}
+		if sampleNames[sample.Name] {
+			return samples, errors.New("Sample name " + sample.Name + " is not unique")
+		}
+		sampleNames[sample.Name] = true
+	}
+
+	return samples, nil
+
+}
+
+// sampleName returns the file name of a read file without its fastq extensions
+func sampleName(file string) string {
+	name := filepath.Base(file)
+	for _, ext := range []string{".gz", ".fastq", ".fq"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
 func parseSearchOptions(searchOpts *search.SearchOptions, w http.ResponseWriter, r *http.Request) error {
 
 	// Input sequence format (string, file, path)
@@ -241,22 +329,178 @@ func parseSearchOptions(searchOpts *search.SearchOptions, w http.ResponseWriter,
 		return errors.New("Need request type (string|file|path)")
 	}
 
+	return parseSearchParameters(searchOpts, r)
+
+}
+
+// parseSearchParameters sets the search parameters of searchOpts (all but the input)
+func parseSearchParameters(searchOpts *search.SearchOptions, r *http.Request) error {
+
 	if r.FormValue("max-results") != "" {
 		if maxRes, err := strconv.Atoi(r.FormValue("max-results")); err == nil {
 			searchOpts.MaxResults = maxRes
 		}
 	}
 
-	if r.FormValue("gcode") != "11" {
-		if gCode, err := strconv.Atoi(r.FormValue("gcode")); err == nil {
-			searchOpts.GeneticCode = gCode
+	if r.FormValue("scoring") != "" {
+		searchOpts.Scoring = strings.ToLower(r.FormValue("scoring"))
+		if !search.ScoringModes[searchOpts.Scoring] {
+			return errors.New("Scoring needs to be kmer, diagonal or idf")
+		}
+	}
+
+	if r.FormValue("kmer-ratio") != "" {
+		kRatio, err := strconv.ParseFloat(r.FormValue("kmer-ratio"), 64)
+		if err != nil || kRatio < 0 || kRatio > 1 {
+			return errors.New("Kmer ratio needs to be between 0 and 1")
 		}
+		searchOpts.KMatchRatio = kRatio
+	}
+
+	if r.FormValue("min-kmer-hits") != "" {
+		minKMatch, err := strconv.ParseInt(r.FormValue("min-kmer-hits"), 10, 64)
+		if err != nil || minKMatch < 1 {
+			return errors.New("Minimum kmer hits needs to be a positive number")
+		}
+		searchOpts.MinKMatch = minKMatch
+	}
+
+	if r.FormValue("top") != "" {
+		top, err := strconv.ParseFloat(r.FormValue("top"), 64)
+		if err != nil || top < 0 || top > 100 {
+			return errors.New("Top hits needs to be a percentage between 0 and 100")
+		}
+		searchOpts.TopPercent = top
+	}
+
+	if strings.ToLower(r.FormValue("best-hits")) == "true" {
+		if searchOpts.TopPercent > 0 {
+			return errors.New("Top hits and best hits cannot be used together")
+		}
+		searchOpts.BestHits = true
+	}
+
+	if r.FormValue("timeout") != "" {
+		timeout, err := strconv.Atoi(r.FormValue("timeout"))
+		if err != nil || timeout < 0 {
+			return errors.New("Timeout needs to be a positive number of seconds")
+		}
+		searchOpts.Timeout = time.Duration(timeout) * time.Second
+	}
+
+	if r.FormValue("gcode") != "" {
+		gCode, err := strconv.Atoi(r.FormValue("gcode"))
+		if err != nil {
+			return errors.New("Genetic code needs to be a number")
+		}
+		searchOpts.GeneticCode = gCode
+	}
+
+	if r.FormValue("orf-mode") != "" {
+		searchOpts.ORFMode = strings.ToLower(r.FormValue("orf-mode"))
+	}
+
+	if r.FormValue("orf-min-length") != "" {
+		minLength, err := strconv.Atoi(r.FormValue("orf-min-length"))
+		if err != nil || minLength < 1 {
+			return errors.New("ORF minimum length needs to be a positive number")
+		}
+		searchOpts.MinORFLength = minLength
+	}
+
+	if strings.ToLower(r.FormValue("resolve-orfs")) == "true" {
+		searchOpts.ResolveORFs = true
+	}
+
+	if r.FormValue("max-overlap") != "" {
+		maxOverlap, err := strconv.Atoi(r.FormValue("max-overlap"))
+		if err != nil || maxOverlap < 0 {
+			return errors.New("ORF maximum overlap needs to be a number >= 0")
+		}
+		searchOpts.MaxORFOverlap = maxOverlap
+	}
+
+	if searchOpts.SequenceType != search.PROTEIN {
+		if err := search.CheckORFOptions(searchOpts.GeneticCode, searchOpts.ORFMode, searchOpts.MinORFLength); err != nil {
+			return err
+		}
+	}
+
+	if strings.ToLower(r.FormValue("lca")) == "true" {
+		searchOpts.LCA = true
+	}
+
+	if r.FormValue("lca-window") != "" {
+		lcaWindow, err := strconv.ParseFloat(r.FormValue("lca-window"), 64)
+		if err != nil || lcaWindow < 0 || lcaWindow > 100 {
+			return errors.New("LCA window needs to be a percentage between 0 and 100")
+		}
+		searchOpts.LCAWindow = lcaWindow
 	}
 
 	if strings.ToLower(r.FormValue("output-format")) == "json" {
 		searchOpts.OutFormat = "json"
 	}
 
+	if strings.ToLower(r.FormValue("output-format")) == "gff" {
+		if searchOpts.SequenceType != search.NUCLEOTIDE {
+			return errors.New("GFF output format is only available for nucleotide searches")
+		}
+		searchOpts.OutFormat = "gff"
+	}
+
+	if strings.ToLower(r.FormValue("output-format")) == "ndjson" {
+		searchOpts.OutFormat = "ndjson"
+	}
+
+	if strings.ToLower(r.FormValue("metadata")) == "true" {
+		searchOpts.Metadata = true
+	}
+
+	if strings.ToLower(r.FormValue("output-format")) == "fasta" {
+		if searchOpts.SequenceType == search.PROTEIN {
+			return errors.New("FASTA output format is only available for fastq and nucleotide searches")
+		}
+		searchOpts.OutFormat = "fasta"
+	}
+
+	if strings.ToLower(r.FormValue("hit-sequences")) == "true" {
+		searchOpts.HitSequences = true
+	}
+
+	if strings.ToLower(r.FormValue("output-format")) == "sam" {
+		if searchOpts.SequenceType == search.PROTEIN {
+			return errors.New("SAM output format is only available for fastq and nucleotide searches")
+		}
+		searchOpts.OutFormat = "sam"
+		// CIGAR comes from the alignment
+		searchOpts.Align = true
+	}
+
+	if strings.ToLower(r.FormValue("output-format")) == "xml" {
+		searchOpts.OutFormat = "xml"
+	}
+
+	if outFormat := strings.ToLower(r.FormValue("output-format")); search.BlastFormats[outFormat] {
+		columns, err := search.ParseBlastColumns(r.FormValue("columns"))
+		if err != nil {
+			return err
+		}
+		searchOpts.OutFormat = outFormat
+		searchOpts.OutColumns = columns
+		// blast columns are alignment values
+		searchOpts.Align = true
+	}
+
+	if outFormat := strings.ToLower(r.FormValue("output-format")); search.RecordFormats[outFormat] {
+		if searchOpts.SequenceType != search.NUCLEOTIDE {
+			return errors.New("GenBank and EMBL output formats are only available for nucleotide searches")
+		}
+		searchOpts.OutFormat = outFormat
+		// records are annotated with a non-redundant gene set
+		searchOpts.ResolveORFs = true
+	}
+
 	if strings.ToLower(r.FormValue("positions")) == "true" {
 		searchOpts.ExtractPositions = true
 	}
@@ -269,6 +513,45 @@ func parseSearchOptions(searchOpts *search.SearchOptions, w http.ResponseWriter,
 		searchOpts.Align = true
 	}
 
+	// alignment cut-offs, the hits are aligned to apply them
+	alignmentCutOffs := []struct {
+		field string
+		value *float64
+		max   float64
+		err   string
+	}{
+		{"max-evalue", &searchOpts.MaxEValue, 0, "Maximum e-value needs to be a positive number"},
+		{"min-identity", &searchOpts.MinIdentity, 100, "Minimum identity needs to be a percentage between 0 and 100"},
+		{"min-query-cover", &searchOpts.MinQueryCover, 100, "Minimum query coverage needs to be a percentage between 0 and 100"},
+		{"min-subject-cover", &searchOpts.MinSubjectCover, 100, "Minimum subject coverage needs to be a percentage between 0 and 100"},
+	}
+	for _, cutOff := range alignmentCutOffs {
+		if r.FormValue(cutOff.field) == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(r.FormValue(cutOff.field), 64)
+		if err != nil || value < 0 || (cutOff.max > 0 && value > cutOff.max) {
+			return errors.New(cutOff.err)
+		}
+		*cutOff.value = value
+		if value > 0 {
+			searchOpts.Align = true
+		}
+	}
+
+	if r.FormValue("summary") != "" {
+		searchOpts.Summary = strings.ToLower(r.FormValue("summary"))
+		if !search.SummaryModes[searchOpts.Summary] {
+			return errors.New("Summary needs to be append or only")
+		}
+		if searchOpts.SequenceType != search.READS {
+			return errors.New("Abundance summary is only available for fastq searches")
+		}
+		if searchOpts.OutFormat != "tsv" && searchOpts.OutFormat != "json" && searchOpts.OutFormat != "ndjson" {
+			return errors.New("Abundance summary is only available in tsv, json and ndjson output formats")
+		}
+	}
+
 	return nil
 
 }
@@ -321,6 +604,54 @@ func fileUploadHandler(r *http.Request, format string) (string, error) {
 	return file, err
 }
 
+// filesUploadHandler saves every uploaded file of the request and returns
+// their paths with their original file names
+func filesUploadHandler(r *http.Request, format string) ([]string, []string, error) {
+
+	var files []string
+	var names []string
+
+	if err := r.ParseMultipartForm(0); err != nil {
+		return files, names, err
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	for _, header := range r.MultipartForm.File["file"] {
+
+		fi, err := header.Open()
+		if err != nil {
+			for _, f := range files {
+				os.Remove(f)
+			}
+			return nil, nil, err
+		}
+
+		guid := xid.New()
+		file := tmpFolder + guid.String() + "." + format
+
+		out, err := os.Create(file)
+		if err == nil {
+			_, err = io.Copy(out, fi)
+			out.Close()
+		}
+		fi.Close()
+		if err != nil {
+			os.Remove(file)
+			for _, f := range files {
+				os.Remove(f)
+			}
+			return nil, nil, err
+		}
+
+		files = append(files, file)
+		names = append(names, header.Filename)
+
+	}
+
+	return files, names, nil
+
+}
+
 func stringUploadHandler(r *http.Request, format string) (string, error) {
 
 	guid := xid.New()
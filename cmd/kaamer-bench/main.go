@@ -161,7 +161,7 @@ func main() {
 			var wg sync.WaitGroup
 			wg.Add(1)
 			go NewMonitor(10, &stop, &wg)
-			makedb.NewMakedb(*dbPath, *inputPath, *inputFmt, *nbThreads, *makedbOffset, *makedbLenght, *maxSize, tableLoadingMode, valueLoadingMode, *noIndex)
+			makedb.NewMakedb(*dbPath, *inputPath, *inputFmt, *nbThreads, *makedbOffset, *makedbLenght, *maxSize, tableLoadingMode, valueLoadingMode, *noIndex, 0, "", "")
 			stop = true
 			wg.Wait()
 		}
@@ -31,6 +31,7 @@ import (
 	"github.com/zorino/kaamer/pkg/makedb"
 	"github.com/zorino/kaamer/pkg/mergedb"
 	"github.com/zorino/kaamer/pkg/restoredb"
+	"github.com/zorino/kaamer/pkg/search"
 )
 
 const (
@@ -54,6 +55,7 @@ func main() {
       -p            port (default: 8321)
       -t            number of threads to use (default all)
       -tmp          tmp folder for query import (default /tmp)
+      -gcode        custom genetic code file (NCBI format) for translated searches
 
       -tableMode    (fileio, memorymap) default memorymap / fileio decreases memory usage
       -valueMode    (fileio, memorymap) default memorymap / fileio decreases memory usage
@@ -68,6 +70,9 @@ func main() {
       -t            number of threads to use (default all)
       -offset       start processing raw uniprot file at protein number x
       -length       process x number of proteins (-1 == infinity)
+      -k            kmer size (5 to 12) default 7
+      -alphabet     kmer alphabet (full, murphy10, diamond11) default full
+      -seed         kmer seed pattern of k kept (1) positions, ex: 1101100111 (default contiguous)
       -tableMode    (fileio, memorymap) default memorymap / fileio decreases memory usage
       -valueMode    (fileio, memorymap) default memorymap / fileio decreases memory usage
     (flag)
@@ -146,6 +151,7 @@ func main() {
 	var portNumber = flag.Int("p", 8321, "port argument")
 	var nbThreads = flag.Int("t", runtime.NumCPU(), "number of threads")
 	var tmpFolder = flag.String("tmp", "/tmp/", "tmp folder for query import")
+	var gcodeFile = flag.String("gcode", "", "custom genetic code file")
 
 	var makedbOpt = flag.Bool("make", false, "program")
 	var inputPath = flag.String("i", "", "input file argument")
@@ -157,6 +163,9 @@ func main() {
 	var tableMode = flag.String("tablemode", "memorymap", "table loading mode (fileio, memorymap)")
 	var valueMode = flag.String("valuemode", "memorymap", "value loading mode (fileio, memorymap)")
 	var noIndex = flag.Bool("noindex", false, "prevent the indexing of database")
+	var kmerSize = flag.Int("k", 7, "kmer size")
+	var alphabet = flag.String("alphabet", "full", "kmer alphabet")
+	var seedPattern = flag.String("seed", "", "kmer seed pattern")
 
 	var indexOpt = flag.Bool("index", false, "program")
 
@@ -208,6 +217,14 @@ func main() {
 		if *dbPath == "" {
 			fmt.Println("No db path !")
 		} else {
+			if *gcodeFile != "" {
+				gcodeId, err := search.LoadGCodeFile(*gcodeFile)
+				if err != nil {
+					fmt.Printf("Invalid genetic code file : %s\n", err.Error())
+					os.Exit(1)
+				}
+				fmt.Printf(" + Loaded custom genetic code %d\n", gcodeId)
+			}
 			server.NewServer(*dbPath, *portNumber, tableLoadingMode, valueLoadingMode, *nbThreads, *tmpFolder)
 		}
 		os.Exit(0)
@@ -263,7 +280,7 @@ func main() {
 			fmt.Println("No input format (-f) !")
 			os.Exit(1)
 		} else {
-			makedb.NewMakedb(*dbPath, *inputPath, *inputFmt, *nbThreads, *makedbOffset, *makedbLenght, *maxSize, tableLoadingMode, valueLoadingMode, *noIndex)
+			makedb.NewMakedb(*dbPath, *inputPath, *inputFmt, *nbThreads, *makedbOffset, *makedbLenght, *maxSize, tableLoadingMode, valueLoadingMode, *noIndex, *kmerSize, *alphabet, *seedPattern)
 		}
 
 		os.Exit(0)
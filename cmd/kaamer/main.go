@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/zorino/kaamer/pkg/search"
 	"github.com/zorino/kaamer/pkg/searchcli"
@@ -29,8 +30,7 @@ import (
 
 var (
 	validQueryType    = map[string]int{"prot": search.PROTEIN, "nt": search.NUCLEOTIDE, "fastq": search.READS}
-	validGeneticCode  = search.GCodes
-	validOutputFormat = map[string]bool{"tsv": true, "json": true}
+	validOutputFormat = map[string]bool{"tsv": true, "json": true, "gff": true, "genbank": true, "embl": true, "blast6": true, "blast7": true, "xml": true, "sam": true, "ndjson": true, "fasta": true}
 )
 
 func main() {
@@ -42,6 +42,9 @@ func main() {
 
   -search           search for a query
 
+  -matrix           search several fastq samples and write their feature by sample read count matrix
+                    (tsv, raw counts of the best hits of the reads)
+
     (input)
 
       -h            server host (default http://localhost:8321)
@@ -50,36 +53,115 @@ func main() {
 
       -g            genetic code for nt/fastq type (default: 11 for bacteria)
 
+      -orf          (start-stop, stop-stop, six-frame) ORF calling mode for nt/fastq type
+                    (default start-stop)
+
+      -orflen       minimum ORF length in amino acids for nt/fastq type (default 21)
+
+      -overlap      maximum overlap in bps between resolved ORFs with -resolve (default 60)
+
       -i            input file (fasta or fastq)
+                    or comma separated fastq files with -matrix
+
+      -samples      comma separated sample names of the -matrix input files (default file names)
+
+      -feature      (protein, taxon, EC, GO, KEGG_Pathways, BioCyc_Pathways, HAMAP) feature counted
+                    in the -matrix rows (default protein)
+
+      -m            max number of results, after -top and not applied with -best (default 10)
+
+      -top          only the hits scoring within this % of the best hit (bit score with -aln,
+                    kmer matches otherwise) (default 0, all the hits)
+
+      -evalue       maximum alignment e-value of the hits (default 0, no cut-off)
+
+      -id           minimum alignment % identity of the hits (default 0)
+
+      -qcov         minimum alignment % query coverage of the hits (default 0)
+
+      -scov         minimum alignment % subject coverage of the hits (default 0)
+
+      -score        (kmer, diagonal, idf) kmer matches scoring : all the shared kmers, only the ones
+                    of the best collinear chain or the shared kmers weighted by their rarity (default kmer)
+
+      -kratio       minimum ratio of query kmers matching a hit (default 0.05)
 
-      -m            max number of results (default 10)
+      -kmin         minimum number of kmers matching a hit (default 10)
+
+      -lcawin       score window (% of the best hit score) of the hits used for the LCA (default 10)
 
       -o            output file (default stdout)
 
-      -fmt          (tsv, json) output format (default tsv)
+      -fmt          (tsv, json, ndjson, gff, genbank, embl, blast6, blast7, xml, sam, fasta) output format (default tsv)
+                    gff, genbank and embl are only available for nt type, sam and fasta for fastq and nt types
+
+      -cols         space or comma separated columns for blast6 and blast7 fmt
+                    (default "qseqid sseqid pident length mismatch gapopen qstart qend sstart send evalue bitscore")
+
+      -timeout      search time budget in seconds, partial results are returned
+                    after this delay (default 0, no timeout)
+
+      -summary      (append, only) abundance summary of a fastq search in tsv, json or ndjson fmt,
+                    after the read results (append) or in their place (only)
 
     (flag)
 
       -aln          do an alignment for query / database hit matches
 
+      -resolve      resolve overlapping ORFs of nt queries into a non-redundant gene set
+
       -ann          add hit annotations in tsv fmt (always true in json fmt)
 
       -pos          add query positions that hit
 
+      -meta         add a leading metadata line in ndjson fmt
+
+      -hitseq       add the hit protein sequences in fasta fmt
+
+      -lca          add the lowest common ancestor (taxonomy) of the best hits of each query / read
+
+      -best         only the best hits of each query (all the ties, -m is not applied), by bit score
+                    with -aln or kmer matches otherwise
+
 `
 
+	defaults := search.DefaultSearchOptions()
+
 	var searchOpt = flag.Bool("search", false, "program")
+	var matrixOpt = flag.Bool("matrix", false, "program")
 
 	var serverHost = flag.String("h", "http://localhost:8321", "server URL")
 	var inputFile = flag.String("i", "", "input file")
 	var queryTypeArg = flag.String("t", "", "query type")
-	var geneticCode = flag.Int("g", 11, "genetic code")
-	var maxResults = flag.Int("m", 10, "max number of results")
+	var geneticCode = flag.Int("g", defaults.GeneticCode, "genetic code")
+	var orfMode = flag.String("orf", defaults.ORFMode, "ORF calling mode")
+	var minORFLength = flag.Int("orflen", defaults.MinORFLength, "minimum ORF length")
+	var maxORFOverlap = flag.Int("overlap", defaults.MaxORFOverlap, "max overlap between resolved ORFs")
+	var maxResults = flag.Int("m", defaults.MaxResults, "max number of results")
+	var topPercent = flag.Float64("top", 0, "top hits percent")
+	var maxEValue = flag.Float64("evalue", 0, "max alignment e-value")
+	var minIdentity = flag.Float64("id", 0, "min alignment identity")
+	var minQueryCover = flag.Float64("qcov", 0, "min alignment query coverage")
+	var minSubjectCover = flag.Float64("scov", 0, "min alignment subject coverage")
+	var scoring = flag.String("score", defaults.Scoring, "kmer scoring mode")
+	var kmerRatio = flag.Float64("kratio", defaults.KMatchRatio, "min ratio of kmer hits")
+	var minKmerHits = flag.Int64("kmin", defaults.MinKMatch, "min number of kmer hits")
+	var lcaWindow = flag.Float64("lcawin", defaults.LCAWindow, "LCA score window")
+	var summary = flag.String("summary", "", "abundance summary mode")
+	var sampleNames = flag.String("samples", "", "matrix sample names")
+	var matrixFeature = flag.String("feature", search.MATRIX_PROTEIN, "matrix feature")
 	var outputFile = flag.String("o", "stdout", "output file")
-	var outputFormat = flag.String("fmt", "tsv", "output format")
+	var outputFormat = flag.String("fmt", defaults.OutFormat, "output format")
+	var outputColumns = flag.String("cols", "", "blast output columns")
+	var timeout = flag.Int("timeout", 0, "search timeout in seconds")
+	var resolveORFs = flag.Bool("resolve", false, "resolve overlapping ORFs flag")
 	var addAlignment = flag.Bool("aln", false, "add alignment flag")
 	var addAnnotation = flag.Bool("ann", false, "add annotation flag")
 	var addPositions = flag.Bool("pos", false, "add position flag")
+	var addMetadata = flag.Bool("meta", false, "add metadata line flag")
+	var addHitSequences = flag.Bool("hitseq", false, "add hit sequences flag")
+	var addLCA = flag.Bool("lca", false, "add LCA flag")
+	var bestHits = flag.Bool("best", false, "best hits flag")
 
 	/* CLI usage */
 	flag.Usage = func() {
@@ -87,7 +169,7 @@ func main() {
 	}
 	flag.Parse()
 
-	if *searchOpt == true {
+	if *searchOpt == true || *matrixOpt == true {
 
 		if *inputFile == "" {
 			fmt.Println("No query intput file !")
@@ -97,18 +179,62 @@ func main() {
 		var ok = false
 		var queryType int
 
+		if *matrixOpt {
+			// samples are read files
+			*queryTypeArg = "fastq"
+		}
+
 		if queryType, ok = validQueryType[*queryTypeArg]; !ok {
 			fmt.Println("Invalid query type ! use prot, nt or reads !")
 			os.Exit(1)
 		}
 
-		if _, ok = validGeneticCode[*geneticCode]; !ok {
-			fmt.Println("Invalid genetic code !")
+		if *matrixOpt && !search.ValidMatrixFeature(*matrixFeature) {
+			fmt.Println("Invalid matrix feature ! use protein, taxon, EC, GO, KEGG_Pathways, BioCyc_Pathways or HAMAP !")
+			os.Exit(1)
+		}
+
+		if _, ok = search.ORFModes[*orfMode]; !ok {
+			fmt.Println("Invalid ORF mode ! use start-stop, stop-stop or six-frame !")
+			os.Exit(1)
+		}
+
+		if !search.ScoringModes[*scoring] {
+			fmt.Println("Invalid scoring mode ! use kmer, diagonal or idf !")
 			os.Exit(1)
 		}
 
 		if _, ok = validOutputFormat[*outputFormat]; !ok {
-			fmt.Println("Invalid output format ! use tsv or json !")
+			fmt.Println("Invalid output format ! use tsv, json, ndjson, gff, genbank, embl, blast6, blast7, xml, sam or fasta !")
+			os.Exit(1)
+		}
+
+		var outColumns []string
+		if search.BlastFormats[*outputFormat] {
+			var err error
+			if outColumns, err = search.ParseBlastColumns(*outputColumns); err != nil {
+				fmt.Printf("Invalid output columns ! %s\n", err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if *topPercent < 0 || *topPercent > 100 {
+			fmt.Println("Invalid top hits ! use a percentage between 0 and 100 !")
+			os.Exit(1)
+		}
+
+		if *topPercent > 0 && *bestHits {
+			fmt.Println("Invalid top hits ! -top and -best cannot be used together !")
+			os.Exit(1)
+		}
+
+		if *maxEValue < 0 || *minIdentity < 0 || *minIdentity > 100 || *minQueryCover < 0 || *minQueryCover > 100 || *minSubjectCover < 0 || *minSubjectCover > 100 {
+			fmt.Println("Invalid alignment cut-off ! use a positive -evalue and percentages between 0 and 100 for -id, -qcov and -scov !")
+			os.Exit(1)
+		}
+
+		if *summary != "" && !search.SummaryModes[*summary] {
+			fmt.Println("Invalid summary mode ! use append or only !")
 			os.Exit(1)
 		}
 
@@ -123,27 +249,83 @@ func main() {
 			OutputFile: *outputFile,
 		}
 
+		inputFiles := []string{*inputFile}
+		if *matrixOpt {
+			inputFiles = strings.Split(*inputFile, ",")
+		}
+
 		hostDomaine := strings.Split(*serverHost, "/")[2]
 		if strings.Contains(hostDomaine, "localhost") || strings.Contains(hostDomaine, "127.0.0.1") {
 			// sequence is on the same host as the server
 			options.InputType = "path"
-			if (*inputFile)[0] != '/' {
-				dir, _ := filepath.Abs(filepath.Dir(os.Args[0]))
-				*inputFile = dir + "/" + *inputFile
+			for i, f := range inputFiles {
+				if f != "" && f[0] != '/' {
+					dir, _ := filepath.Abs(filepath.Dir(os.Args[0]))
+					inputFiles[i] = dir + "/" + f
+				}
 			}
 		} else {
 			// remote server
 			options.InputType = "file"
 		}
 
-		options.File = *inputFile
+		options.File = inputFiles[0]
 		options.SequenceType = queryType
 		options.GeneticCode = *geneticCode
+		options.ORFMode = *orfMode
+		options.MinORFLength = *minORFLength
+		options.ResolveORFs = *resolveORFs
+		options.MaxORFOverlap = *maxORFOverlap
 		options.OutFormat = *outputFormat
+		options.OutColumns = outColumns
 		options.MaxResults = *maxResults
+		options.Scoring = *scoring
+		options.TopPercent = *topPercent
+		options.BestHits = *bestHits
+		options.KMatchRatio = *kmerRatio
+		options.MinKMatch = *minKmerHits
+		options.Timeout = time.Duration(*timeout) * time.Second
 		options.Align = *addAlignment
+		options.MaxEValue = *maxEValue
+		options.MinIdentity = *minIdentity
+		options.MinQueryCover = *minQueryCover
+		options.MinSubjectCover = *minSubjectCover
 		options.ExtractPositions = *addPositions
 		options.Annotations = *addAnnotation
+		options.Metadata = *addMetadata
+		options.HitSequences = *addHitSequences
+		options.LCA = *addLCA
+		options.LCAWindow = *lcaWindow
+		options.Summary = *summary
+
+		if *matrixOpt {
+
+			samples := []search.Sample{}
+			names := []string{}
+			if *sampleNames != "" {
+				names = strings.Split(*sampleNames, ",")
+				if len(names) != len(inputFiles) {
+					fmt.Println("Invalid sample names ! use one sample name per input file !")
+					os.Exit(1)
+				}
+			}
+			for i, f := range inputFiles {
+				sample := search.Sample{File: f}
+				if len(names) > 0 {
+					sample.Name = names[i]
+				}
+				samples = append(samples, sample)
+			}
+
+			options.Summary = ""
+			searchcli.NewMatrixRequest(searchcli.MatrixRequestOptions{
+				SearchRequestOptions: options,
+				Samples:              samples,
+				Feature:              *matrixFeature,
+			})
+			os.Exit(0)
+
+		}
 
 		searchcli.NewSearchRequest(options)
 
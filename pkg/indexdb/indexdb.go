@@ -28,7 +28,6 @@ import (
 	"github.com/dgraph-io/badger"
 	"github.com/dgraph-io/badger/options"
 	"github.com/dgraph-io/badger/pb"
-	"github.com/golang/protobuf/proto"
 	"github.com/zorino/kaamer/pkg/kvstore"
 )
 
@@ -180,20 +179,11 @@ func AddSettings(kvStores *kvstore.KVStores, dbPath string) {
 	}
 
 	// Add settings to protein store
-	ksettings := &kvstore.KSettings{
-		Name:            dbName,
-		Port:            8321,
-		DatabaseIndexed: true,
-		IDsIndexed:      false,
-	}
-	data, err := proto.Marshal(ksettings)
-	if err != nil {
-		log.Fatal(err.Error())
-	}
-
-	kvStores.ProteinStore.KVStore.OpenInsertChannel()
-	kvStores.ProteinStore.AddValueToChannel([]byte("db_settings"), data, true)
-	kvStores.ProteinStore.KVStore.CloseInsertChannel()
-	kvStores.ProteinStore.Flush()
+	ksettings := kvStores.Settings()
+	ksettings.Name = dbName
+	ksettings.Port = 8321
+	ksettings.DatabaseIndexed = true
+	ksettings.IDsIndexed = false
+	kvStores.SetSettings(ksettings)
 
 }
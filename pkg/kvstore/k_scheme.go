@@ -0,0 +1,148 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	DEFAULT_KMER_SIZE = 7 // number of residues encoded in a kmer key
+	MIN_KMER_SIZE     = 5
+	MAX_KMER_SIZE     = 12 // 9 bits per residue pair in 64-bit keys
+
+	ALPHABET_FULL      = "full"
+	ALPHABET_MURPHY10  = "murphy10"
+	ALPHABET_DIAMOND11 = "diamond11"
+)
+
+// Alphabets are the residue groups of the kmer alphabets, the residues of a
+// group are all indexed as its first residue
+var Alphabets = map[string][]string{
+	ALPHABET_FULL:      nil,
+	ALPHABET_MURPHY10:  {"LVIM", "C", "A", "G", "ST", "P", "FYW", "EDNQ", "KR", "H"},
+	ALPHABET_DIAMOND11: {"KREDQN", "C", "G", "H", "ILV", "M", "F", "Y", "W", "P", "STA"},
+}
+
+// KScheme is the kmer index scheme of a database : the number of residues of
+// the kmers, their alphabet and the seed pattern ('1' for a kept position, '0'
+// for a skipped one) selecting the KmerSize residues of a window
+type KScheme struct {
+	KmerSize    int
+	Alphabet    string
+	SeedPattern string
+	exact       bool
+	reduce      [256]byte
+	seed        []int
+}
+
+// NewKScheme returns the index scheme of kmerSize, alphabet and seedPattern,
+// empty values are DEFAULT_KMER_SIZE, the full alphabet and contiguous kmers
+func NewKScheme(kmerSize int, alphabet string, seedPattern string) (*KScheme, error) {
+
+	if kmerSize == 0 {
+		kmerSize = DEFAULT_KMER_SIZE
+	}
+	if kmerSize < MIN_KMER_SIZE || kmerSize > MAX_KMER_SIZE {
+		return nil, fmt.Errorf("Kmer size must be between %d and %d", MIN_KMER_SIZE, MAX_KMER_SIZE)
+	}
+
+	if alphabet == "" {
+		alphabet = ALPHABET_FULL
+	}
+	if seedPattern == "" {
+		seedPattern = strings.Repeat("1", kmerSize)
+	}
+
+	groups, ok := Alphabets[alphabet]
+	if !ok {
+		return nil, fmt.Errorf("Unknown alphabet %s", alphabet)
+	}
+
+	if strings.Trim(seedPattern, "01") != "" {
+		return nil, errors.New("Seed pattern must only contain 1 (kept) and 0 (skipped) positions")
+	}
+	if seedPattern[0] != '1' || seedPattern[len(seedPattern)-1] != '1' {
+		return nil, errors.New("Seed pattern must start and end with a kept position")
+	}
+	if strings.Count(seedPattern, "1") != kmerSize {
+		return nil, fmt.Errorf("Seed pattern must keep %d positions (kmer size)", kmerSize)
+	}
+
+	scheme := &KScheme{KmerSize: kmerSize, Alphabet: alphabet, SeedPattern: seedPattern}
+
+	for i := range scheme.reduce {
+		scheme.reduce[i] = byte(i)
+	}
+	for _, g := range groups {
+		for i := 0; i < len(g); i++ {
+			scheme.reduce[g[i]] = g[0]
+		}
+	}
+
+	for i, p := range seedPattern {
+		if p == '1' {
+			scheme.seed = append(scheme.seed, i)
+		}
+	}
+
+	scheme.exact = alphabet == ALPHABET_FULL && !strings.Contains(seedPattern, "0")
+
+	return scheme, nil
+
+}
+
+// Equals returns true if both schemes index the same kmers
+func (s *KScheme) Equals(other *KScheme) bool {
+	return s.KmerSize == other.KmerSize && s.Alphabet == other.Alphabet && s.SeedPattern == other.SeedPattern
+}
+
+func (s *KScheme) String() string {
+	return fmt.Sprintf("%d-mers, %s alphabet and seed pattern %s", s.KmerSize, s.Alphabet, s.SeedPattern)
+}
+
+// KeySize returns the number of bytes of the kmer keys : 9 bits per residue
+// pair and 5 bits for the last residue of odd kmers, in 32 or 64-bit keys
+func (s *KScheme) KeySize() int {
+	if (s.KmerSize/2)*9+(s.KmerSize%2)*5 > 32 {
+		return 8
+	}
+	return 4
+}
+
+// Span returns the length of the sequence windows indexed by a kmer
+func (s *KScheme) Span() int {
+	return len(s.SeedPattern)
+}
+
+// Kmer returns the indexed kmer of a window of Span residues
+func (s *KScheme) Kmer(window string) string {
+
+	if s.exact {
+		return window
+	}
+
+	kmer := make([]byte, len(s.seed))
+	for i, p := range s.seed {
+		kmer[i] = s.reduce[window[p]]
+	}
+
+	return string(kmer)
+
+}
@@ -24,6 +24,7 @@ import (
 // Kmer Entries
 type K_ struct {
 	*KVStore
+	Scheme     *KScheme
 	aaTable    map[[2]rune]uint32
 	aaBinTable map[uint32][2]rune
 }
@@ -32,6 +33,7 @@ func K_New(opts badger.Options, flushSize int, nbOfThreads int) *K_ {
 	var k K_
 	k.KVStore = new(KVStore)
 	k.aaTable, k.aaBinTable = NewAATable()
+	k.Scheme, _ = NewKScheme(DEFAULT_KMER_SIZE, ALPHABET_FULL, "")
 	NewKVStore(k.KVStore, opts, flushSize, nbOfThreads)
 	return &k
 }
@@ -63,11 +65,15 @@ func NewAATable() (map[[2]rune]uint32, map[uint32][2]rune) {
 
 }
 
-func (k *K_) CreateBytesKey(kmer string) []byte {
-	// expect kmers of length 7
-	kmerInt := k.EncodeKmer(kmer)
-	byteArrayKmer := make([]byte, 4)
-	binary.BigEndian.PutUint32(byteArrayKmer, kmerInt)
+func (k *K_) CreateBytesKey(window string) []byte {
+	// expect windows of length Scheme.Span()
+	kmerInt := k.EncodeKmer(k.Scheme.Kmer(window))
+	byteArrayKmer := make([]byte, k.Scheme.KeySize())
+	if len(byteArrayKmer) == 4 {
+		binary.BigEndian.PutUint32(byteArrayKmer, uint32(kmerInt))
+	} else {
+		binary.BigEndian.PutUint64(byteArrayKmer, kmerInt)
+	}
 
 	// kmerString := fmt.Sprintf("%x", kmerInt)
 	// kmerHex := hex.EncodeToString(byteArrayKmer)
@@ -87,28 +93,32 @@ func (k *K_) CreateBytesVal(entry string) []byte {
 	return byteArrayKmer
 }
 
-
-// expect kmers of length 7
-func (k *K_) EncodeKmer(kmer string) uint32 {
+// expect kmers of length Scheme.KmerSize
+// aa pairs are encoded on 9 bits from the most significant bits of the key
+// (Scheme.KeySize) and the last aa of odd kmers on the 5 least significant bits
+func (k *K_) EncodeKmer(kmer string) uint64 {
 
 	// fmt.Println("#Encoding")
 
-	kmerInt := uint32(0)
+	keyBits := uint(k.Scheme.KeySize() * 8)
+	kmerInt := uint64(0)
 	i := 0
-	shiftIndex := uint8(1)
+	shiftIndex := uint(1)
 
 	// aa pairs
-	for (i + 2) < len(kmer) {
+	for (i + 1) < len(kmer) {
 		// fmt.Printf("%s => %x\n", kmer[i:i+2], aaTable[kmer[i:i+2]])
 		_key := [2]rune{rune(kmer[i]), rune(kmer[i+1])}
-		kmerInt |= k.aaTable[_key] << (32 - (shiftIndex * 9))
+		kmerInt |= uint64(k.aaTable[_key]) << (keyBits - (shiftIndex * 9))
 		shiftIndex++
 		i += 2
 	}
 
 	// last aa
-	_key := [2]rune{rune(kmer[len(kmer)-1]), '.'}
-	kmerInt |= k.aaTable[_key]
+	if i < len(kmer) {
+		_key := [2]rune{rune(kmer[i]), '.'}
+		kmerInt |= uint64(k.aaTable[_key])
+	}
 
 	// fmt.Printf("%s => %x\n", kmer[len(kmer)-1:], aaTable[kmer[len(kmer)-1:]])
 	// fmt.Println(kmerInt)
@@ -117,35 +127,34 @@ func (k *K_) EncodeKmer(kmer string) uint32 {
 
 }
 
-// expect kmers of length 7
+// expect keys of Scheme.KeySize bytes
 func (k *K_) DecodeKmer(key []byte) string {
 
-	kmerInt := binary.BigEndian.Uint32(key)
-	aa := (kmerInt >> 23) & 0x1FF
-	bb := (kmerInt >> 14) & 0x1FF
-	cc := (kmerInt >> 5) & 0x1FF
-	dd := (kmerInt) & 0x1F
+	keyBits := uint(len(key) * 8)
+	kmerInt := uint64(0)
+	if len(key) == 4 {
+		kmerInt = uint64(binary.BigEndian.Uint32(key))
+	} else {
+		kmerInt = binary.BigEndian.Uint64(key)
+	}
 
 	// fmt.Println("#Decoding")
-	// fmt.Printf("%s => %x\n", aaBinTable[aa], aa)
-	// fmt.Printf("%s => %x\n", aaBinTable[bb], bb)
-	// fmt.Printf("%s => %x\n", aaBinTable[cc], cc)
-	// fmt.Printf("%s => %x\n", aaBinTable[dd], dd)
 
 	kmer := ""
-	kmer += string(k.aaBinTable[aa][0])
-	kmer += string(k.aaBinTable[aa][1])
-	kmer += string(k.aaBinTable[bb][0])
-	kmer += string(k.aaBinTable[bb][1])
-	kmer += string(k.aaBinTable[cc][0])
-	kmer += string(k.aaBinTable[cc][1])
-	kmer += string(k.aaBinTable[dd][0])
+	for shiftIndex := uint(1); shiftIndex <= uint(k.Scheme.KmerSize/2); shiftIndex++ {
+		aa := uint32(kmerInt>>(keyBits-(shiftIndex*9))) & 0x1FF
+		kmer += string(k.aaBinTable[aa][0])
+		kmer += string(k.aaBinTable[aa][1])
+	}
+	if k.Scheme.KmerSize%2 == 1 {
+		dd := uint32(kmerInt) & 0x1F
+		kmer += string(k.aaBinTable[dd][0])
+	}
 
 	return kmer
 
 }
 
-
 func (k *K_) EncodeEntry(kmer string) uint32 {
 
 	// fmt.Println("#Encoding")
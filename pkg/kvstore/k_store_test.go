@@ -0,0 +1,72 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvstore
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeDecodeKmer(t *testing.T) {
+
+	aa := "ACDEFGHIKLMNPQRSTUVWY"
+	r := rand.New(rand.NewSource(1))
+
+	for kmerSize := MIN_KMER_SIZE; kmerSize <= MAX_KMER_SIZE; kmerSize++ {
+
+		scheme, err := NewKScheme(kmerSize, ALPHABET_FULL, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		k := &K_{Scheme: scheme}
+		k.aaTable, k.aaBinTable = NewAATable()
+
+		// 32-bit keys up to 7-mers, 64-bit keys above
+		keySize := 4
+		if kmerSize > 7 {
+			keySize = 8
+		}
+
+		kmers := []string{}
+		for _, a := range aa {
+			kmer := ""
+			for len(kmer) < kmerSize {
+				kmer += string(a)
+			}
+			kmers = append(kmers, kmer)
+		}
+		for i := 0; i < 1000; i++ {
+			kmer := make([]byte, kmerSize)
+			for j := range kmer {
+				kmer[j] = aa[r.Intn(len(aa))]
+			}
+			kmers = append(kmers, string(kmer))
+		}
+
+		for _, kmer := range kmers {
+			key := k.CreateBytesKey(kmer)
+			if len(key) != keySize {
+				t.Fatalf("%d-mer key has %d bytes, expected %d", kmerSize, len(key), keySize)
+			}
+			if decoded := k.DecodeKmer(key); decoded != kmer {
+				t.Fatalf("%s is decoded as %s (%d-mers)", kmer, decoded, kmerSize)
+			}
+		}
+
+	}
+
+}
@@ -49,7 +49,9 @@ func (kc *KC_) CreateKCKeyValue(keys [][]byte) ([]byte, []byte) {
 		kComb.ProteinKeys = append(kComb.ProteinKeys, intId)
 		h.Write(k)
 	}
-	kComb.Count = 0
+	// number of proteins sharing the kmers of this combination, it is
+	// len(ProteinKeys) (the kmers document frequency of the idf scoring)
+	kComb.Count = uint32(len(kComb.ProteinKeys))
 
 	kCombPB, err := proto.Marshal(kComb)
 	if err != nil {
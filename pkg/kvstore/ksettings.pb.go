@@ -28,6 +28,9 @@ type KSettings struct {
 	DatabaseIndexed      bool     `protobuf:"varint,5,opt,name=DatabaseIndexed,proto3" json:"DatabaseIndexed,omitempty"`
 	IDsIndexed           bool     `protobuf:"varint,6,opt,name=IDsIndexed,proto3" json:"IDsIndexed,omitempty"`
 	NamesIndexed         bool     `protobuf:"varint,7,opt,name=NamesIndexed,proto3" json:"NamesIndexed,omitempty"`
+	Alphabet             string   `protobuf:"bytes,8,opt,name=Alphabet,proto3" json:"Alphabet,omitempty"`
+	SeedPattern          string   `protobuf:"bytes,9,opt,name=SeedPattern,proto3" json:"SeedPattern,omitempty"`
+	KmerSize             int32    `protobuf:"varint,10,opt,name=KmerSize,proto3" json:"KmerSize,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -107,6 +110,27 @@ func (m *KSettings) GetNamesIndexed() bool {
 	return false
 }
 
+func (m *KSettings) GetAlphabet() string {
+	if m != nil {
+		return m.Alphabet
+	}
+	return ""
+}
+
+func (m *KSettings) GetSeedPattern() string {
+	if m != nil {
+		return m.SeedPattern
+	}
+	return ""
+}
+
+func (m *KSettings) GetKmerSize() int32 {
+	if m != nil {
+		return m.KmerSize
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*KSettings)(nil), "kvstore.KSettings")
 }
@@ -114,17 +138,20 @@ func init() {
 func init() { proto.RegisterFile("ksettings.proto", fileDescriptor_4e477fb09697567a) }
 
 var fileDescriptor_4e477fb09697567a = []byte{
-	// 190 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0xcf, 0x4b, 0xaa, 0xc2, 0x30,
-	0x14, 0xc6, 0x71, 0x72, 0x6f, 0x1f, 0x36, 0x08, 0x85, 0x8c, 0x32, 0x92, 0xd2, 0x51, 0x46, 0x4e,
-	0x5c, 0x82, 0x45, 0x28, 0x82, 0x4a, 0x5d, 0x41, 0x4a, 0x0f, 0x25, 0xb4, 0x26, 0x92, 0x1c, 0xc4,
-	0x2d, 0xbb, 0x0b, 0x49, 0x7c, 0xd0, 0x3a, 0xfb, 0xf8, 0xe7, 0x07, 0x49, 0x68, 0x3e, 0x38, 0x40,
-	0x54, 0xba, 0x77, 0xeb, 0xab, 0x35, 0x68, 0x58, 0x3a, 0xdc, 0x1c, 0x1a, 0x0b, 0xe5, 0x83, 0xd0,
-	0x6c, 0x7f, 0x7e, 0x1f, 0x32, 0x46, 0xa3, 0x83, 0xbc, 0x00, 0x27, 0x05, 0x11, 0x59, 0x13, 0xb6,
-	0x6f, 0x27, 0x63, 0x91, 0xff, 0x15, 0x44, 0xc4, 0x4d, 0xd8, 0xac, 0xa4, 0xcb, 0xad, 0x05, 0x89,
-	0xca, 0xe8, 0x4a, 0x22, 0xf0, 0xff, 0xe0, 0x67, 0xcd, 0x9b, 0xa3, 0x55, 0xbd, 0xd2, 0x72, 0xdc,
-	0xa9, 0x11, 0x78, 0xf4, 0x32, 0xd3, 0xc6, 0x04, 0xcd, 0x2b, 0x89, 0xb2, 0x95, 0x0e, 0x6a, 0xdd,
-	0xc1, 0x1d, 0x3a, 0x1e, 0x17, 0x44, 0x2c, 0x9a, 0xdf, 0xcc, 0x56, 0x94, 0xd6, 0x95, 0xfb, 0xa0,
-	0x24, 0xa0, 0x49, 0xf1, 0xb7, 0xf9, 0xd7, 0x7e, 0x45, 0x1a, 0xc4, 0xac, 0xb5, 0x49, 0xf8, 0xfb,
-	0xe6, 0x19, 0x00, 0x00, 0xff, 0xff, 0x3c, 0x54, 0x48, 0x74, 0x0e, 0x01, 0x00, 0x00,
+	// 233 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x90, 0xcf, 0x4a, 0xc3, 0x40,
+	0x10, 0xc6, 0x49, 0xed, 0x9f, 0x64, 0x14, 0x0a, 0x7b, 0x1a, 0x3c, 0x48, 0xe8, 0x29, 0x27, 0x2f,
+	0x3e, 0x81, 0x18, 0x84, 0x52, 0xd0, 0x92, 0x3c, 0xc1, 0x84, 0x0c, 0x75, 0x69, 0xba, 0x5b, 0x76,
+	0x07, 0x11, 0x5f, 0xcf, 0x17, 0x93, 0x1d, 0x35, 0xa4, 0xde, 0x66, 0x7e, 0xf3, 0x5b, 0xf6, 0xe3,
+	0x83, 0xf5, 0x31, 0xb2, 0x88, 0x75, 0x87, 0x78, 0x7f, 0x0e, 0x5e, 0xbc, 0x59, 0x1d, 0xdf, 0xa3,
+	0xf8, 0xc0, 0x9b, 0xaf, 0x19, 0x14, 0xbb, 0xf6, 0xf7, 0x68, 0x0c, 0xcc, 0x5f, 0xe8, 0xc4, 0x98,
+	0x95, 0x59, 0x55, 0x34, 0x3a, 0x27, 0xb6, 0xf7, 0x41, 0x70, 0x56, 0x66, 0xd5, 0xa2, 0xd1, 0xd9,
+	0x6c, 0xe0, 0xe6, 0x29, 0x30, 0x89, 0xf5, 0xae, 0x26, 0x61, 0xbc, 0x52, 0xff, 0x82, 0x25, 0xe7,
+	0x35, 0xd8, 0x83, 0x75, 0x34, 0x3c, 0xdb, 0x81, 0x71, 0xfe, 0xe3, 0x4c, 0x99, 0xa9, 0x60, 0x5d,
+	0x93, 0x50, 0x47, 0x91, 0xb7, 0xae, 0xe7, 0x0f, 0xee, 0x71, 0x51, 0x66, 0x55, 0xde, 0xfc, 0xc7,
+	0xe6, 0x0e, 0x60, 0x5b, 0xc7, 0x3f, 0x69, 0xa9, 0xd2, 0x84, 0xa4, 0xdf, 0x52, 0xda, 0xd1, 0x58,
+	0xa9, 0x71, 0xc1, 0xcc, 0x2d, 0xe4, 0x8f, 0xc3, 0xf9, 0x8d, 0x3a, 0x16, 0xcc, 0x35, 0xcd, 0xb8,
+	0x9b, 0x12, 0xae, 0x5b, 0xe6, 0x7e, 0x4f, 0x22, 0x1c, 0x1c, 0x16, 0x7a, 0x9e, 0xa2, 0xf4, 0x7a,
+	0x77, 0xe2, 0xd0, 0xda, 0x4f, 0x46, 0xd0, 0x2e, 0xc6, 0xbd, 0x5b, 0x6a, 0xab, 0x0f, 0xdf, 0x03,
+	0x00, 0x40, 0xe8, 0x9e, 0x71, 0x68, 0x01, 0x00, 0x00,
 }
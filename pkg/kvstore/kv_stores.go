@@ -17,10 +17,12 @@ limitations under the License.
 package kvstore
 
 import (
+	"log"
 	"math"
 
 	"github.com/dgraph-io/badger"
 	"github.com/dgraph-io/badger/options"
+	proto "github.com/golang/protobuf/proto"
 )
 
 // # Stores :
@@ -108,10 +110,53 @@ func KVStoresNew(dbPath string, nbOfThreads int, tableLoadingMode options.FileLo
 	kvStores.KCombStore = KC_New(kc_opts, 1000, nbOfThreads)
 	kvStores.ProteinStore = P_New(p_opts, 1000, nbOfThreads)
 
+	// kmers are encoded with the index scheme of the database
+	settings := kvStores.Settings()
+	scheme, err := NewKScheme(int(settings.KmerSize), settings.Alphabet, settings.SeedPattern)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	kvStores.KmerStore.Scheme = scheme
+
 	return &kvStores
 
 }
 
+// Settings returns the database settings (empty settings if none)
+func (kvStores *KVStores) Settings() *KSettings {
+
+	ksettings := &KSettings{}
+	data, ok := kvStores.ProteinStore.GetValue([]byte("db_settings"))
+	if ok {
+		if err := proto.Unmarshal(data, ksettings); err != nil {
+			log.Fatal(err.Error())
+		}
+	}
+
+	return ksettings
+
+}
+
+// SetSettings replaces the database settings, the index scheme of the
+// database is kept
+func (kvStores *KVStores) SetSettings(ksettings *KSettings) {
+
+	ksettings.KmerSize = int32(kvStores.KmerStore.Scheme.KmerSize)
+	ksettings.Alphabet = kvStores.KmerStore.Scheme.Alphabet
+	ksettings.SeedPattern = kvStores.KmerStore.Scheme.SeedPattern
+
+	data, err := proto.Marshal(ksettings)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	kvStores.ProteinStore.KVStore.OpenInsertChannel()
+	kvStores.ProteinStore.AddValueToChannel([]byte("db_settings"), data, true)
+	kvStores.ProteinStore.KVStore.CloseInsertChannel()
+	kvStores.ProteinStore.Flush()
+
+}
+
 func (kvStores *KVStores) OpenInsertChannel() {
 	kvStores.KmerStore.OpenInsertChannel()
 	kvStores.KCombStore.OpenInsertChannel()
@@ -54,6 +54,7 @@ func runEMBL(fileName string, kvStores *kvstore.KVStores, nbThreads int, offset
 
 	jobs := make(chan ProteinBufEMBL)
 	results := make(chan int32, 10)
+	kmerSpan := kvStores.KmerStore.Scheme.Span()
 	wg := new(sync.WaitGroup)
 
 	// thread pool
@@ -136,7 +137,7 @@ func runEMBL(fileName string, kvStores *kvstore.KVStores, nbThreads int, offset
 	for v := range results {
 		countProteins += 1
 		countAA += uint64(v)
-		countKmers += uint64(v) - KMER_SIZE + 1
+		countKmers += uint64(v) - uint64(kmerSpan) + 1
 		if countProteins%10000 == 0 {
 			fmt.Printf("Processed %d proteins in %f minutes\n", countProteins, time.Since(timeStart).Minutes())
 		}
@@ -188,6 +189,8 @@ func readBufferEMBL(jobs <-chan ProteinBufEMBL, results chan<- int32, wg *sync.W
 
 func processProteinInputEMBL(proteinBuf ProteinBufEMBL, results chan<- int32, kvStores *kvstore.KVStores) {
 
+	kmerSpan := kvStores.KmerStore.Scheme.Span()
+
 	textEntry := proteinBuf.proteinEntry
 	protein := &kvstore.Protein{}
 	features := map[string]string{}
@@ -287,7 +290,7 @@ func processProteinInputEMBL(proteinBuf ProteinBufEMBL, results chan<- int32, kv
 	}
 
 	// skip peptide shorter than kmerSize
-	if protein.Length < KMER_SIZE {
+	if protein.Length < int32(kmerSpan) {
 		return
 	}
 
@@ -306,8 +309,8 @@ func processProteinInputEMBL(proteinBuf ProteinBufEMBL, results chan<- int32, kv
 	}
 
 	// sliding windows of kmerSize on Sequence
-	for i := 0; i < int(protein.Length)-KMER_SIZE+1; i++ {
-		kmerKey := kvStores.KmerStore.CreateBytesKey(protein.Sequence[i : i+KMER_SIZE])
+	for i := 0; i < int(protein.Length)-kmerSpan+1; i++ {
+		kmerKey := kvStores.KmerStore.CreateBytesKey(protein.Sequence[i : i+kmerSpan])
 		kvStores.KmerStore.AddValueToChannel(kmerKey, proteinId, false)
 	}
 
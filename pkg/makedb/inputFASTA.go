@@ -52,6 +52,7 @@ func runFASTA(fileName string, kvStores *kvstore.KVStores, nbThreads int, offset
 
 	jobs := make(chan ProteinBufFASTA)
 	results := make(chan int32, 10)
+	kmerSpan := kvStores.KmerStore.Scheme.Span()
 	wg := new(sync.WaitGroup)
 
 	// thread pool
@@ -142,7 +143,7 @@ func runFASTA(fileName string, kvStores *kvstore.KVStores, nbThreads int, offset
 	for v := range results {
 		countProteins += 1
 		countAA += uint64(v)
-		countKmers += uint64(v) - KMER_SIZE + 1
+		countKmers += uint64(v) - uint64(kmerSpan) + 1
 		if countProteins%10000 == 0 {
 			fmt.Printf("Processed %d proteins in %f minutes\n", countProteins, time.Since(timeStart).Minutes())
 		}
@@ -194,6 +195,8 @@ func readBufferFASTA(jobs <-chan ProteinBufFASTA, results chan<- int32, wg *sync
 
 func processProteinInputFASTA(proteinBuf ProteinBufFASTA, results chan<- int32, kvStores *kvstore.KVStores) {
 
+	kmerSpan := kvStores.KmerStore.Scheme.Span()
+
 	textEntry := proteinBuf.proteinEntry
 	protein := &kvstore.Protein{}
 	features := map[string]string{}
@@ -223,7 +226,7 @@ func processProteinInputFASTA(proteinBuf ProteinBufFASTA, results chan<- int32,
 	protein.Length = int32(len(protein.Sequence))
 
 	// skip peptide shorter than kmerSize
-	if protein.Length < KMER_SIZE {
+	if protein.Length < int32(kmerSpan) {
 		return
 	}
 
@@ -242,8 +245,8 @@ func processProteinInputFASTA(proteinBuf ProteinBufFASTA, results chan<- int32,
 	}
 
 	// sliding windows of kmerSize on Sequence
-	for i := 0; i < int(protein.Length)-KMER_SIZE+1; i++ {
-		kmerKey := kvStores.KmerStore.CreateBytesKey(protein.Sequence[i : i+KMER_SIZE])
+	for i := 0; i < int(protein.Length)-kmerSpan+1; i++ {
+		kmerKey := kvStores.KmerStore.CreateBytesKey(protein.Sequence[i : i+kmerSpan])
 		kvStores.KmerStore.AddValueToChannel(kmerKey, proteinId, false)
 	}
 
@@ -53,6 +53,7 @@ func runGBK(fileName string, kvStores *kvstore.KVStores, nbThreads int, offset u
 
 	jobs := make(chan ProteinBufGBK)
 	results := make(chan int32, 10)
+	kmerSpan := kvStores.KmerStore.Scheme.Span()
 	wg := new(sync.WaitGroup)
 
 	// thread pool
@@ -135,7 +136,7 @@ func runGBK(fileName string, kvStores *kvstore.KVStores, nbThreads int, offset u
 	for v := range results {
 		countProteins += 1
 		countAA += uint64(v)
-		countKmers += uint64(v) - KMER_SIZE + 1
+		countKmers += uint64(v) - uint64(kmerSpan) + 1
 		if countProteins%10000 == 0 {
 			fmt.Printf("Processed %d proteins in %f minutes\n", countProteins, time.Since(timeStart).Minutes())
 		}
@@ -187,6 +188,8 @@ func readBufferGBK(jobs <-chan ProteinBufGBK, results chan<- int32, wg *sync.Wai
 
 func processProteinInputGBK(proteinBuf ProteinBufGBK, results chan<- int32, kvStores *kvstore.KVStores) {
 
+	kmerSpan := kvStores.KmerStore.Scheme.Span()
+
 	textEntry := proteinBuf.proteinEntry
 	protein := &kvstore.Protein{}
 	features := map[string]string{}
@@ -266,7 +269,7 @@ func processProteinInputGBK(proteinBuf ProteinBufGBK, results chan<- int32, kvSt
 	protein.Length = int32(len(protein.Sequence))
 
 	// skip peptide shorter than kmerSize
-	if protein.Length < KMER_SIZE {
+	if protein.Length < int32(kmerSpan) {
 		return
 	}
 
@@ -287,8 +290,8 @@ func processProteinInputGBK(proteinBuf ProteinBufGBK, results chan<- int32, kvSt
 	}
 
 	// sliding windows of kmerSize on Sequence
-	for i := 0; i < int(protein.Length)-KMER_SIZE+1; i++ {
-		kmerKey := kvStores.KmerStore.CreateBytesKey(protein.Sequence[i : i+KMER_SIZE])
+	for i := 0; i < int(protein.Length)-kmerSpan+1; i++ {
+		kmerKey := kvStores.KmerStore.CreateBytesKey(protein.Sequence[i : i+kmerSpan])
 		kvStores.KmerStore.AddValueToChannel(kmerKey, proteinId, false)
 	}
 
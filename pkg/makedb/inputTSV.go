@@ -48,6 +48,7 @@ func runTSV(fileName string, kvStores *kvstore.KVStores, nbThreads int, offset u
 
 	jobs := make(chan ProteinBufTSV)
 	results := make(chan int32, 10)
+	kmerSpan := kvStores.KmerStore.Scheme.Span()
 	wg := new(sync.WaitGroup)
 
 	// thread pool
@@ -135,7 +136,7 @@ func runTSV(fileName string, kvStores *kvstore.KVStores, nbThreads int, offset u
 			}
 
 			// skip peptide shorter than kmerSize
-			if protein.Length < KMER_SIZE || protein.Sequence == "" || protein.EntryId == "" {
+			if protein.Length < int32(kmerSpan) || protein.Sequence == "" || protein.EntryId == "" {
 				continue
 			}
 			jobs <- ProteinBufTSV{proteinId: proteinNb, proteinEntry: *protein}
@@ -161,7 +162,7 @@ func runTSV(fileName string, kvStores *kvstore.KVStores, nbThreads int, offset u
 	for v := range results {
 		countProteins += 1
 		countAA += uint64(v)
-		countKmers += uint64(v) - KMER_SIZE + 1
+		countKmers += uint64(v) - uint64(kmerSpan) + 1
 		if countProteins%10000 == 0 {
 			fmt.Printf("Processed %d proteins in %f minutes\n", countProteins, time.Since(timeStart).Minutes())
 		}
@@ -233,8 +234,9 @@ func processProteinInputTSV(proteinBuf ProteinBufTSV, results chan<- int32, kvSt
 	}
 
 	// sliding windows of kmerSize on Sequence
-	for i := 0; i < int(proteinBuf.proteinEntry.Length)-KMER_SIZE+1; i++ {
-		kmerKey := kvStores.KmerStore.CreateBytesKey(proteinBuf.proteinEntry.Sequence[i : i+KMER_SIZE])
+	kmerSpan := kvStores.KmerStore.Scheme.Span()
+	for i := 0; i < int(proteinBuf.proteinEntry.Length)-kmerSpan+1; i++ {
+		kmerKey := kvStores.KmerStore.CreateBytesKey(proteinBuf.proteinEntry.Sequence[i : i+kmerSpan])
 		kvStores.KmerStore.AddValueToChannel(kmerKey, proteinId, false)
 	}
 
@@ -27,11 +27,7 @@ import (
 	"github.com/zorino/kaamer/pkg/kvstore"
 )
 
-const (
-	KMER_SIZE = 7 // 7 is currently the only supported kmer size
-)
-
-func NewMakedb(dbPath string, inputPath string, inputFmt string, threadByWorker int, offset uint, lenght uint, maxSize bool, tableLoadingMode options.FileLoadingMode, valueLoadingMode options.FileLoadingMode, noIndex bool) {
+func NewMakedb(dbPath string, inputPath string, inputFmt string, threadByWorker int, offset uint, lenght uint, maxSize bool, tableLoadingMode options.FileLoadingMode, valueLoadingMode options.FileLoadingMode, noIndex bool, kmerSize int, alphabet string, seedPattern string) {
 
 	runtime.GOMAXPROCS(128)
 
@@ -46,7 +42,24 @@ func NewMakedb(dbPath string, inputPath string, inputFmt string, threadByWorker
 	fmt.Printf("# Making Database %s from %s\n", dbPath, inputPath)
 	fmt.Printf("# Using %d CPU\n", threadByWorker)
 
+	scheme, err := kvstore.NewKScheme(kmerSize, alphabet, seedPattern)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Printf("# Kmer index of %s\n", scheme)
+
 	kvStores := kvstore.KVStoresNew(dbPath, threadByWorker, tableLoadingMode, valueLoadingMode, maxSize, false, false)
+
+	// proteins added to an existing database must be indexed the same way
+	if settings := kvStores.Settings(); settings.SeedPattern != "" && !kvStores.KmerStore.Scheme.Equals(scheme) {
+		fmt.Printf("Database %s is indexed with %s !\n", dbPath, kvStores.KmerStore.Scheme)
+		os.Exit(1)
+	}
+	kvStores.KmerStore.Scheme = scheme
+	kvStores.SetSettings(kvStores.Settings())
+
 	kvStores.OpenInsertChannel()
 
 	switch inputFmt {
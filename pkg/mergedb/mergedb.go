@@ -82,6 +82,11 @@ func NewMergedb(dbsPath string, outPath string, maxSize bool, tableLoadingMode o
 
 			kvStores2 := kvstore.KVStoresNew(db, 1, tableLoadingMode, valueLoadingMode, maxSize, false, false)
 
+			if !kvStores1.KmerStore.Scheme.Equals(kvStores2.KmerStore.Scheme) {
+				fmt.Printf("Database %s is not indexed with the kmer scheme of %s\n", db, outPath)
+				os.Exit(1)
+			}
+
 			_dbStats := &kvstore.KStats{}
 			_dbStatsByte, ok := kvStores2.ProteinStore.GetValue([]byte("db_stats"))
 			if !ok {
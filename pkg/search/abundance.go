@@ -0,0 +1,251 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+const (
+	SUMMARY_APPEND = "append" // summary after the read results
+	SUMMARY_ONLY   = "only"   // summary in place of the read results
+)
+
+var (
+	SummaryModes = map[string]bool{SUMMARY_APPEND: true, SUMMARY_ONLY: true}
+
+	// SummaryFeatures are the hit annotations aggregated in the abundance summary
+	SummaryFeatures = []string{"EC", "GO", "KEGG_Pathways", "BioCyc_Pathways", "HAMAP"}
+)
+
+// AbundanceCount is the number of reads assigned to a protein, an annotation
+// value or a taxon with its length-normalised abundances
+type AbundanceCount struct {
+	Id        string  `json:"id"`
+	Reads     int64   `json:"reads"`
+	RPK       float64 `json:"rpk"`       // reads per kilobase of the hit proteins coding sequence
+	Abundance float64 `json:"abundance"` // RPK per million of the sample RPK
+}
+
+// AbundanceSummary is the sample-level abundance report of a read search
+type AbundanceSummary struct {
+	Reads    int64                       `json:"reads"`
+	Proteins []AbundanceCount            `json:"proteins"`
+	Features map[string][]AbundanceCount `json:"features"`
+	Taxa     []AbundanceCount            `json:"taxa"`
+}
+
+// Abundance aggregates the read results of a search by best hit protein,
+// annotation values (SummaryFeatures) and taxon (LCA if computed or best
+// hit organism).
+// A read counts once : the ORFs of a read (same query name) are reduced to
+// the best hit of its best scoring ORF.
+type Abundance struct {
+	reads map[string]readHit
+}
+
+// readHit is the best hit of a read with the taxon of its ORF
+type readHit struct {
+	score float64
+	hit   kvstore.Protein
+	taxon string
+}
+
+func NewAbundance() *Abundance {
+	return &Abundance{reads: map[string]readHit{}}
+}
+
+// Collect adds the query results received from queryResultIn to the
+// abundance and forwards them to queryResultOut if forward.
+// queryResultOut is closed once queryResultIn is.
+func (a *Abundance) Collect(queryResultIn <-chan QueryResult, queryResultOut chan<- QueryResult, forward bool, wg *sync.WaitGroup) {
+
+	defer wg.Done()
+	defer close(queryResultOut)
+
+	for qR := range queryResultIn {
+		a.Add(qR)
+		if forward {
+			queryResultOut <- qR
+		}
+	}
+
+}
+
+// Add keeps the best hit of a read result (ORF) if it is better than the
+// best hit of the other ORFs of the read
+func (a *Abundance) Add(qR QueryResult) {
+
+	if len(qR.SearchResults.Hits) == 0 {
+		return
+	}
+
+	// best hit of the ORF, ties go to the lowest entry id so reads count the
+	// same way whatever the order of equal hits
+	score := 0.0
+	var hit kvstore.Protein
+	for i, h := range qR.SearchResults.Hits {
+		entry := qR.HitEntries[h.Key]
+		hScore := h.Score(true)
+		if i == 0 || hScore > score || (hScore == score && entry.EntryId < hit.EntryId) {
+			score, hit = hScore, entry
+		}
+	}
+	if hit.Length < 1 {
+		return
+	}
+
+	taxon := strings.TrimSpace(hit.Features["Organism"])
+	if qR.LCA != nil {
+		taxon = qR.LCA.Taxon
+	}
+	if taxon == "" {
+		taxon = "unclassified"
+	}
+
+	// ORFs of a read arrive in any order, same tie rule
+	best, ok := a.reads[qR.Query.Name]
+	if ok && (best.score > score || (best.score == score && best.hit.EntryId <= hit.EntryId)) {
+		return
+	}
+
+	a.reads[qR.Query.Name] = readHit{score: score, hit: hit, taxon: taxon}
+
+}
+
+// abundanceCounts are the read counts by protein, annotation value and taxon
+type abundanceCounts struct {
+	proteins map[string]*AbundanceCount
+	features map[string]map[string]*AbundanceCount
+	taxa     map[string]*AbundanceCount
+}
+
+// count aggregates the best hit of every read
+func (a *Abundance) count() abundanceCounts {
+
+	counts := abundanceCounts{
+		proteins: map[string]*AbundanceCount{},
+		features: map[string]map[string]*AbundanceCount{},
+		taxa:     map[string]*AbundanceCount{},
+	}
+	for _, f := range SummaryFeatures {
+		counts.features[f] = map[string]*AbundanceCount{}
+	}
+
+	for _, r := range a.reads {
+
+		rpk := 1000 / (float64(r.hit.Length) * 3)
+
+		addAbundanceCount(counts.proteins, r.hit.EntryId, rpk)
+
+		for _, f := range SummaryFeatures {
+			for _, value := range strings.Split(r.hit.Features[f], ";") {
+				if value = strings.TrimSpace(ecEvidence.ReplaceAllString(value, "")); value != "" {
+					addAbundanceCount(counts.features[f], value, rpk)
+				}
+			}
+		}
+
+		addAbundanceCount(counts.taxa, r.taxon, rpk)
+
+	}
+
+	return counts
+
+}
+
+// Summary returns the abundance counts sorted by decreasing number of reads
+func (a *Abundance) Summary() AbundanceSummary {
+
+	counts := a.count()
+
+	totalRPK := 0.0
+	for _, c := range counts.proteins {
+		totalRPK += c.RPK
+	}
+
+	summary := AbundanceSummary{
+		Reads:    int64(len(a.reads)),
+		Proteins: sortedAbundanceCounts(counts.proteins, totalRPK),
+		Features: map[string][]AbundanceCount{},
+		Taxa:     sortedAbundanceCounts(counts.taxa, totalRPK),
+	}
+	for f, featureCounts := range counts.features {
+		summary.Features[f] = sortedAbundanceCounts(featureCounts, totalRPK)
+	}
+
+	return summary
+
+}
+
+// FormatAbundanceTSV returns the abundance summary as a tsv table
+func FormatAbundanceTSV(summary AbundanceSummary) string {
+
+	output := "Type\tId\tReads\tRPK\tAbundance\n"
+
+	formatCounts := func(countType string, counts []AbundanceCount) {
+		for _, c := range counts {
+			output += fmt.Sprintf("%s\t%s\t%d\t%.4f\t%.4f\n", countType, c.Id, c.Reads, c.RPK, c.Abundance)
+		}
+	}
+
+	formatCounts("protein", summary.Proteins)
+	for _, f := range SummaryFeatures {
+		formatCounts(f, summary.Features[f])
+	}
+	formatCounts("taxon", summary.Taxa)
+
+	return output
+
+}
+
+func addAbundanceCount(counts map[string]*AbundanceCount, id string, rpk float64) {
+	c, ok := counts[id]
+	if !ok {
+		c = &AbundanceCount{Id: id}
+		counts[id] = c
+	}
+	c.Reads++
+	c.RPK += rpk
+}
+
+func sortedAbundanceCounts(counts map[string]*AbundanceCount, totalRPK float64) []AbundanceCount {
+
+	sortedCounts := []AbundanceCount{}
+	for _, c := range counts {
+		count := *c
+		if totalRPK > 0 {
+			count.Abundance = count.RPK / totalRPK * 1000000
+		}
+		sortedCounts = append(sortedCounts, count)
+	}
+
+	sort.Slice(sortedCounts, func(i, j int) bool {
+		if sortedCounts[i].Reads == sortedCounts[j].Reads {
+			return sortedCounts[i].Id < sortedCounts[j].Id
+		}
+		return sortedCounts[i].Reads > sortedCounts[j].Reads
+	})
+
+	return sortedCounts
+
+}
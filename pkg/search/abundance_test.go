@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"testing"
+
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+func orfResult(read string, entryId string, kmatch int64) QueryResult {
+	return QueryResult{
+		Query:         Query{Name: read},
+		SearchResults: &SearchResults{Hits: HitList{{Key: 1, Kmatch: kmatch}}},
+		HitEntries: map[uint32]kvstore.Protein{
+			1: {EntryId: entryId, Length: 100, Features: map[string]string{"EC": entryId + "_EC", "Organism": "Escherichia coli"}},
+		},
+	}
+}
+
+func TestAbundanceCountsReadsOnce(t *testing.T) {
+
+	abundance := NewAbundance()
+	// read1 yields two ORFs, the second one is its best
+	abundance.Add(orfResult("read1", "RS2_ECOLI", 12))
+	abundance.Add(orfResult("read1", "RS19_ECOLI", 30))
+	abundance.Add(orfResult("read2", "RS2_ECOLI", 20))
+
+	summary := abundance.Summary()
+	if summary.Reads != 2 {
+		t.Errorf("Summary has %d reads, expected 2", summary.Reads)
+	}
+	if len(summary.Taxa) != 1 || summary.Taxa[0].Reads != 2 {
+		t.Errorf("Taxa counts are %+v, expected 2 reads of Escherichia coli", summary.Taxa)
+	}
+
+}
+
+func TestAbundanceMatrixCountsReadsOnce(t *testing.T) {
+
+	abundance := NewAbundance()
+	// read1 yields two ORFs hitting different proteins
+	abundance.Add(orfResult("read1", "RS2_ECOLI", 12))
+	abundance.Add(orfResult("read1", "RS19_ECOLI", 30))
+	abundance.Add(orfResult("read2", "RS2_ECOLI", 20))
+
+	proteins := abundance.Counts(MATRIX_PROTEIN)
+	if proteins["RS2_ECOLI"] != 1 || proteins["RS19_ECOLI"] != 1 {
+		t.Errorf("Protein counts are %v, expected 1 read for each protein", proteins)
+	}
+	ec := abundance.Counts("EC")
+	if ec["RS2_ECOLI_EC"] != 1 || ec["RS19_ECOLI_EC"] != 1 {
+		t.Errorf("EC counts are %v, expected 1 read for each EC", ec)
+	}
+	if taxa := abundance.Counts(MATRIX_TAXON); taxa["Escherichia coli"] != 2 {
+		t.Errorf("Taxon counts are %v, expected 2 reads of Escherichia coli", taxa)
+	}
+
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"sort"
+
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+const (
+	DIAGONAL_MAX_SHIFT = 8 // max diagonal shift (indel) between chained kmers
+)
+
+type kmerPair struct {
+	qPos int
+	sPos int
+}
+
+// ScoreDiagonals replaces the kmer matches (Kmatch) of the best hits by the
+// number of shared kmers in their best collinear chain : kmers matching in
+// the same order in the query and the hit, on diagonals (query position
+// minus hit position) shifted by at most DIAGONAL_MAX_SHIFT between
+// consecutive kmers.
+// A chain has at most Kmatch kmers, so the hits are rescored by decreasing
+// Kmatch until the next ones can't be kept by the -best, -top or -m filters
+// whatever their chain (see diagonalBound), they are then ranked by their new
+// Kmatch.
+func (queryResult *QueryResult) ScoreDiagonals(kvStores *kvstore.KVStores, searchOptions SearchOptions) {
+
+	scheme := kvStores.KmerStore.Scheme
+	kmerSpan := scheme.Span()
+	queryKmers := kmerPositions(queryResult.Query.Sequence, scheme)
+
+	// rescored Kmatch, decreasing
+	scores := []int64{}
+
+	for i, h := range queryResult.SearchResults.Hits {
+
+		if float64(h.Kmatch) < diagonalBound(scores, searchOptions) || !queryResult.fetchHitEntry(kvStores, h.Key) {
+			for _, dropped := range queryResult.SearchResults.Hits[i:] {
+				queryResult.removeHit(dropped.Key)
+			}
+			queryResult.SearchResults.Hits = queryResult.SearchResults.Hits[:i]
+			break
+		}
+
+		hitSeq := queryResult.HitEntries[h.Key].Sequence
+		pairs := []kmerPair{}
+		for j := 0; j+kmerSpan <= len(hitSeq); j++ {
+			for _, p := range queryKmers[scheme.Kmer(hitSeq[j:j+kmerSpan])] {
+				pairs = append(pairs, kmerPair{qPos: p, sPos: j})
+			}
+		}
+
+		score := bestChainLength(pairs)
+		queryResult.SearchResults.Hits[i].Kmatch = score
+
+		n := sort.Search(len(scores), func(k int) bool { return scores[k] < score })
+		scores = append(scores, 0)
+		copy(scores[n+1:], scores[n:])
+		scores[n] = score
+
+	}
+
+	sort.SliceStable(queryResult.SearchResults.Hits, func(i, j int) bool {
+		return queryResult.SearchResults.Hits[i].Kmatch > queryResult.SearchResults.Hits[j].Kmatch
+	})
+
+}
+
+// diagonalBound returns the Kmatch below which a hit can't be kept, from
+// the decreasing scores of the hits rescored so far : the best score with
+// -best (ties are kept), the -top score and the score of the MaxResults-th
+// hit otherwise.
+// With alignment, -best and -top rank the hits on their bit score so they
+// have no bound.
+func diagonalBound(scores []int64, searchOptions SearchOptions) float64 {
+
+	if len(scores) == 0 {
+		return 0
+	}
+
+	topFilters := searchOptions.BestHits || searchOptions.TopPercent > 0
+	if searchOptions.Align && topFilters {
+		return 0
+	}
+
+	if searchOptions.BestHits {
+		return float64(scores[0])
+	}
+
+	bound := 0.0
+	if searchOptions.TopPercent > 0 {
+		bound = float64(scores[0]) * (1 - searchOptions.TopPercent/100)
+	}
+	if searchOptions.MaxResults > 0 && len(scores) >= searchOptions.MaxResults {
+		if nth := float64(scores[searchOptions.MaxResults-1]); nth > bound {
+			bound = nth
+		}
+	}
+
+	return bound
+
+}
+
+// bestChainLength returns the number of kmer pairs of the longest chain
+// increasing in query and hit positions with diagonal shifts of at most
+// DIAGONAL_MAX_SHIFT between consecutive pairs.
+// Pairs are swept by query position and bucketed by diagonal : the previous
+// pair of a chain is looked up in the 2 * DIAGONAL_MAX_SHIFT + 1 neighbouring
+// diagonals, so repeated kmers (low complexity regions) stay O(n log n).
+func bestChainLength(pairs []kmerPair) int64 {
+
+	if len(pairs) == 0 {
+		return 0
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].qPos == pairs[j].qPos {
+			return pairs[i].sPos < pairs[j].sPos
+		}
+		return pairs[i].qPos < pairs[j].qPos
+	})
+
+	// diagonals (qPos - sPos) are indexed from the lowest one
+	minDiagonal, maxDiagonal := pairs[0].qPos-pairs[0].sPos, pairs[0].qPos-pairs[0].sPos
+	for _, p := range pairs {
+		if d := p.qPos - p.sPos; d < minDiagonal {
+			minDiagonal = d
+		} else if d > maxDiagonal {
+			maxDiagonal = d
+		}
+	}
+	diagonals := make([]diagonalChains, maxDiagonal-minDiagonal+1)
+
+	best := 0
+	for _, p := range pairs {
+
+		diagonal := p.qPos - p.sPos
+
+		chain := 1
+		for d := diagonal - DIAGONAL_MAX_SHIFT; d <= diagonal+DIAGONAL_MAX_SHIFT; d++ {
+			if d < minDiagonal || d > maxDiagonal {
+				continue
+			}
+			// pairs of diagonal d before p in the query and the hit
+			bound := p.qPos
+			if p.sPos+d < bound {
+				bound = p.sPos + d
+			}
+			if prev := diagonals[d-minDiagonal].bestBefore(bound); prev+1 > chain {
+				chain = prev + 1
+			}
+		}
+
+		diagonals[diagonal-minDiagonal].add(p.qPos, chain)
+
+		if chain > best {
+			best = chain
+		}
+
+	}
+
+	return int64(best)
+
+}
+
+// diagonalChains are the chains ending on the pairs of a diagonal, in
+// increasing query positions with the running best chain length
+type diagonalChains struct {
+	qPos []int
+	best []int
+}
+
+func (c *diagonalChains) add(qPos int, chain int) {
+	if n := len(c.best); n > 0 && c.best[n-1] > chain {
+		chain = c.best[n-1]
+	}
+	c.qPos = append(c.qPos, qPos)
+	c.best = append(c.best, chain)
+}
+
+// bestBefore returns the longest chain ending before query position qPos
+func (c *diagonalChains) bestBefore(qPos int) int {
+	n := sort.SearchInts(c.qPos, qPos)
+	if n == 0 {
+		return 0
+	}
+	return c.best[n-1]
+}
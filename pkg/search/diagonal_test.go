@@ -0,0 +1,175 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/options"
+	"github.com/golang/protobuf/proto"
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+// naiveChainLength is the quadratic chaining bestChainLength replaces
+func naiveChainLength(pairs []kmerPair) int64 {
+	best := 0
+	chain := make([]int, len(pairs))
+	for i, p := range pairs {
+		chain[i] = 1
+		for j := range pairs {
+			prev := pairs[j]
+			if prev.qPos >= p.qPos || prev.sPos >= p.sPos {
+				continue
+			}
+			shift := (p.qPos - p.sPos) - (prev.qPos - prev.sPos)
+			if shift <= DIAGONAL_MAX_SHIFT && shift >= -DIAGONAL_MAX_SHIFT && chain[j]+1 > chain[i] {
+				chain[i] = chain[j] + 1
+			}
+		}
+		if chain[i] > best {
+			best = chain[i]
+		}
+	}
+	return int64(best)
+}
+
+func TestBestChainLength(t *testing.T) {
+
+	r := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 200; n++ {
+		seen := map[kmerPair]bool{}
+		pairs := []kmerPair{}
+		for i := 0; i < r.Intn(60); i++ {
+			p := kmerPair{qPos: r.Intn(50), sPos: r.Intn(50)}
+			if !seen[p] {
+				seen[p] = true
+				pairs = append(pairs, p)
+			}
+		}
+		// sorted by query position, as bestChainLength sorts them
+		sorted := append([]kmerPair{}, pairs...)
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].qPos == sorted[j].qPos {
+				return sorted[i].sPos < sorted[j].sPos
+			}
+			return sorted[i].qPos < sorted[j].qPos
+		})
+		expected := naiveChainLength(sorted)
+		if chain := bestChainLength(pairs); chain != expected {
+			t.Fatalf("Chain length of %v is %d, expected %d", pairs, chain, expected)
+		}
+	}
+
+}
+
+func TestBestChainLengthPolyQ(t *testing.T) {
+
+	scheme, err := kvstore.NewKScheme(0, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polyQ := strings.Repeat("Q", 1000)
+	queryKmers := kmerPositions(polyQ, scheme)
+	pairs := []kmerPair{}
+	for j := 0; j+scheme.Span() <= len(polyQ); j++ {
+		for _, p := range queryKmers[scheme.Kmer(polyQ[j:j+scheme.Span()])] {
+			pairs = append(pairs, kmerPair{qPos: p, sPos: j})
+		}
+	}
+
+	start := time.Now()
+	chain := bestChainLength(pairs)
+	if expected := int64(len(polyQ) - scheme.Span() + 1); chain != expected {
+		t.Errorf("Poly-Q chain length is %d, expected %d", chain, expected)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Poly-Q chaining of %d pairs took %s", len(pairs), elapsed)
+	}
+
+}
+
+func TestScoreDiagonalsRescoredWinner(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "kaamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kvStores := kvstore.KVStoresNew(dir, 1, options.MemoryMap, options.MemoryMap, false, false, false)
+	defer kvStores.Close()
+
+	query := "MKVLAAGIVGLLLAAQPAMAHHHHHHWEDCRSTYNPFKLQ"
+
+	// 6 hits sharing more kmers than the homolog but scattered (reversed
+	// query), the homolog ranks past 5 x -m 1 by raw kmer count
+	proteins := map[uint32]string{7: query}
+	for key := uint32(1); key <= 6; key++ {
+		runes := []rune(query)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		proteins[key] = string(runes)
+	}
+	for key, seq := range proteins {
+		proteinId := make([]byte, 4)
+		binary.BigEndian.PutUint32(proteinId, key)
+		val, err := proto.Marshal(&kvstore.Protein{Sequence: seq, Length: int32(len(seq))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		kvStores.ProteinStore.UpdateValue(proteinId, val)
+	}
+
+	newQueryResult := func() *QueryResult {
+		qR := &QueryResult{
+			Query:         Query{Sequence: query},
+			SearchResults: &SearchResults{PositionHits: map[uint32][]bool{}},
+			HitEntries:    map[uint32]kvstore.Protein{},
+		}
+		for key := uint32(1); key <= 7; key++ {
+			kmatch := int64(30)
+			if key == 7 {
+				kmatch = 20
+			}
+			qR.SearchResults.Hits = append(qR.SearchResults.Hits, Hit{Key: key, Kmatch: kmatch})
+			qR.SearchResults.PositionHits[key] = []bool{}
+		}
+		return qR
+	}
+
+	expected := int64(len(query) - kvStores.KmerStore.Scheme.Span() + 1)
+
+	for _, opts := range []SearchOptions{{MaxResults: 1}, {MaxResults: 10, BestHits: true}} {
+		qR := newQueryResult()
+		qR.ScoreDiagonals(kvStores, opts)
+		hits := qR.SearchResults.Hits
+		if len(hits) == 0 || hits[0].Key != 7 || hits[0].Kmatch != expected {
+			t.Errorf("Diagonal scoring with %+v ranked %+v first, expected the hit 7 with %d kmers", opts, hits, expected)
+		}
+	}
+
+}
@@ -17,13 +17,23 @@ limitations under the License.
 package search
 
 import (
+	"errors"
+	"fmt"
 	"sort"
 	"strings"
 )
 
+const (
+	ORF_START_STOP          = "start-stop"
+	ORF_STOP_STOP           = "stop-stop"
+	ORF_SIX_FRAME           = "six-frame"
+	DEFAULT_MIN_ORF_LENGTH  = 21
+	DEFAULT_MAX_ORF_OVERLAP = 60 // bps
+)
+
 var (
 	frameStartPosition = map[int]int{0: 0, 1: 1, 2: 2, 3: 0, 4: 1, 5: 2}
-	minLenCDS          = 21
+	ORFModes           = map[string]bool{ORF_START_STOP: true, ORF_STOP_STOP: true, ORF_SIX_FRAME: true}
 )
 
 type AminoAcid struct {
@@ -36,6 +46,7 @@ type Location struct {
 	StartPosition     int
 	EndPosition       int
 	PlusStrand        bool
+	Frame             int // 1, 2, 3 or -1, -2, -3 for ORFs, 0 otherwise
 	StartsAlternative []int
 }
 
@@ -62,9 +73,29 @@ func ReverseComplement(dna string) string {
 
 }
 
-func GetORFs(dna string, geneticCode int) []ORF {
+// GetORFs translates the 6 frames of dna and returns the ORFs found with
+// orfMode :
+//
+//	ORF_START_STOP  from a start codon (or the sequence start) to a stop codon
+//	ORF_STOP_STOP   from a stop codon (or the sequence start) to the next one
+//	ORF_SIX_FRAME   the whole translated frames, stop codons included
+//
+// ORFs shorter than minLength amino acids are discarded.
+func GetORFs(dna string, geneticCode int, orfMode string, minLength int) ([]ORF, error) {
+
+	if err := CheckORFOptions(geneticCode, orfMode, minLength); err != nil {
+		return nil, err
+	}
+
+	gcode, _ := GetGeneticCode(geneticCode)
+	if orfMode == "" {
+		orfMode = ORF_START_STOP
+	}
 
 	orfs := []ORF{}
+	if len(dna) < 3 {
+		return orfs, nil
+	}
 	dna = strings.ToLower(dna)
 	frames := []string{
 		GetFrame(1, dna),
@@ -79,16 +110,29 @@ func GetORFs(dna string, geneticCode int) []ORF {
 
 		startPos := frameStartPosition[framePos]
 		plusStrand := framePos <= 2
+		frame := framePos + 1
+		if !plusStrand {
+			frame = -(framePos - 2)
+		}
 		absPos := framePos
 		if !plusStrand {
 			absPos = len(dna) - startPos - 1
 		}
 		currentPos := 0
 
+		// position of the first base of the codon at i in the frame
+		codonPosition := func(i int) int {
+			if !plusStrand {
+				return len(dna) - (framePos + i) + 3
+			}
+			return framePos + i + 1
+		}
+
 		loc := Location{
 			StartPosition:     absPos + 1,
 			EndPosition:       0,
 			PlusStrand:        plusStrand,
+			Frame:             frame,
 			StartsAlternative: []int{},
 		}
 		orf := ORF{
@@ -103,16 +147,13 @@ func GetORFs(dna string, geneticCode int) []ORF {
 
 		for i := 0; i < len(frameSeq)-(len(frameSeq)%3); i += 3 {
 			currentPos = i
-			currentAA = gcodeBacteria[frameSeq[i:i+3]]
+			currentAA = gcode[frameSeq[i:i+3]]
 
-			if currentAA.Start {
+			if currentAA.Start && orfMode == ORF_START_STOP {
 				if insideORF == false {
 					insideORF = true
 					currentAAPos = 0
-					orf.Location.StartPosition = framePos + i + 1
-					if !plusStrand {
-						orf.Location.StartPosition = len(dna) - (framePos + i) + 3
-					}
+					orf.Location.StartPosition = codonPosition(i)
 					orf.Location.StartsAlternative = append(orf.Location.StartsAlternative, currentAAPos)
 				} else {
 					// new possible start
@@ -124,8 +165,8 @@ func GetORFs(dna string, geneticCode int) []ORF {
 				cds += currentAA.AA
 			}
 
-			if currentAA.Stop {
-				if insideORF && len(cds) >= minLenCDS {
+			if currentAA.Stop && orfMode != ORF_SIX_FRAME {
+				if insideORF && cds != "" && len(cds) >= minLength {
 					endPos := i + 3 + framePos
 					if !plusStrand {
 						endPos = orf.Location.StartPosition - (len(cds) * 3) + 1
@@ -139,6 +180,7 @@ func GetORFs(dna string, geneticCode int) []ORF {
 					StartPosition:     0,
 					EndPosition:       0,
 					PlusStrand:        plusStrand,
+					Frame:             frame,
 					StartsAlternative: []int{},
 				}
 				orf = ORF{
@@ -147,12 +189,17 @@ func GetORFs(dna string, geneticCode int) []ORF {
 				}
 				cds = ""
 				insideORF = false
+				if orfMode == ORF_STOP_STOP {
+					// next ORF starts right after the stop codon
+					insideORF = true
+					orf.Location.StartPosition = codonPosition(i + 3)
+				}
 			}
 
 			currentAAPos += 1
 		}
 
-		if insideORF && len(cds) >= minLenCDS {
+		if insideORF && cds != "" && len(cds) >= minLength {
 			endPos := currentPos + 3 + framePos
 			if !plusStrand {
 				endPos = orf.Location.StartPosition - (len(cds) * 3) + 1
@@ -176,7 +223,28 @@ func GetORFs(dna string, geneticCode int) []ORF {
 		return iPos < jPos
 	})
 
-	return orfs
+	return orfs, nil
+
+}
+
+// CheckORFOptions returns an error if the genetic code or the ORF mode
+// (empty for the default ORF_START_STOP) is not supported, or if the minimum
+// ORF length is less than 1 amino acid
+func CheckORFOptions(geneticCode int, orfMode string, minLength int) error {
+
+	if _, err := GetGeneticCode(geneticCode); err != nil {
+		return err
+	}
+
+	if _, ok := ORFModes[orfMode]; !ok && orfMode != "" {
+		return fmt.Errorf("ORF mode %s is not supported", orfMode)
+	}
+
+	if minLength < 1 {
+		return errors.New("ORF minimum length needs to be a positive number")
+	}
+
+	return nil
 
 }
 
@@ -260,10 +328,7 @@ func SetBestStartCodon(queryResult *QueryResult) {
 		for _k, _positions := range queryResult.SearchResults.PositionHits {
 			queryResult.SearchResults.PositionHits[_k] = _positions[bestStart:]
 		}
-		queryResult.Query.SizeInKmer = len(queryResult.Query.Sequence) - KMER_SIZE + 1
-		if queryResult.Query.Sequence[len(queryResult.Query.Sequence)-1:] == "*" {
-			queryResult.Query.SizeInKmer = queryResult.Query.SizeInKmer - 1
-		}
+		queryResult.Query.SizeInKmer = queryResult.Query.SizeInKmer - bestStart
 
 	}
 
@@ -271,136 +336,83 @@ func SetBestStartCodon(queryResult *QueryResult) {
 
 }
 
-/*
-Below are unused functions
-Keep for furtur references
-*/
-
-func ResolveORFs(queryResults []QueryResult) []QueryResult {
+// ResolveORFs returns a non-redundant gene set from the ORF results of a
+// contig : ORFs are kept by decreasing best hit score (kmer matches) unless
+// they overlap an already kept ORF by more than maxOverlap bps.
+// The gene set is sorted by contig position.
+func ResolveORFs(queryResults []QueryResult, maxOverlap int) []QueryResult {
 
 	goodResults := new([]QueryResult)
-	var startPositions []int
-	var endPositions []int
 
-	// sort query with most hits first
-	sort.Slice(queryResults[:], func(i, j int) bool {
+	// sort query with most hits first, longest first for ties
+	sort.SliceStable(queryResults[:], func(i, j int) bool {
 		if len(queryResults[j].SearchResults.Hits) == 0 {
-			return true
+			return len(queryResults[i].SearchResults.Hits) > 0
 		} else if len(queryResults[i].SearchResults.Hits) == 0 {
 			return false
 		}
-		return queryResults[i].SearchResults.Hits[0].Kmatch > queryResults[j].SearchResults.Hits[0].Kmatch
+		iScore := queryResults[i].SearchResults.Hits[0].Kmatch
+		jScore := queryResults[j].SearchResults.Hits[0].Kmatch
+		if iScore == jScore {
+			return len(queryResults[i].Query.Sequence) > len(queryResults[j].Query.Sequence)
+		}
+		return iScore > jScore
 	})
 
 	for _, r := range queryResults {
-		SetBestStartCodon(&r)
-		PruneORFs(r, &startPositions, &endPositions, goodResults)
+		PruneORFs(r, goodResults, maxOverlap)
 	}
 
+	sort.SliceStable(*goodResults, func(i, j int) bool {
+		iStart, _ := (*goodResults)[i].Query.Location.Bounds()
+		jStart, _ := (*goodResults)[j].Query.Location.Bounds()
+		return iStart < jStart
+	})
+
 	return *goodResults
 
 }
 
-func PruneORFs(queryResult QueryResult, startPositions *[]int, endPositions *[]int, goodResults *[]QueryResult) {
+// PruneORFs adds queryResult to goodResults unless it has no hit or it
+// overlaps one of the goodResults by more than maxOverlap bps
+// (see https://bmcgenomics.biomedcentral.com/articles/10.1186/1471-2164-9-335)
+func PruneORFs(queryResult QueryResult, goodResults *[]QueryResult, maxOverlap int) {
 
 	if len(queryResult.SearchResults.Hits) == 0 {
 		return
 	}
 
-	// Set start and end position in same order for +/- strand
-	queryStart := 0
-	queryEnd := 0
-
-	if queryResult.Query.Location.PlusStrand {
-		queryStart = queryResult.Query.Location.StartPosition
-		queryEnd = queryResult.Query.Location.EndPosition
-	} else {
-		queryStart = queryResult.Query.Location.EndPosition
-		queryEnd = queryResult.Query.Location.StartPosition
-	}
-
-	if len(*startPositions) == 0 {
-		// fmt.Println("Adding because startposition empty")
-		*startPositions = append(*startPositions, queryStart)
-		*endPositions = append(*endPositions, queryEnd)
-		*goodResults = append(*goodResults, queryResult)
-		return
-	}
-
-	ePos := 0
-	overlappedPositions := false
-
-	for i, sPos := range *startPositions {
-
-		ePos = (*endPositions)[i]
-
-		// If overlap is > 60 bps discard
-		// see https://bmcgenomics.biomedcentral.com/articles/10.1186/1471-2164-9-335
-
-		// Case left overlap
-		// -----|-------|--------
-		// ---|------*-----|-----
-		if queryStart < sPos &&
-			queryEnd > sPos {
-
-			overlappedPositions = true
-			overlap := queryEnd - sPos
-			if overlap < 60 {
-				// fmt.Println("Adding left overlap")
-				*startPositions = append(*startPositions, queryStart)
-				*endPositions = append(*endPositions, queryEnd)
-				*goodResults = append(*goodResults, queryResult)
-			}
-
-		}
-
-		// Case right overlap
-		// -----|-------|--------
-		// -------|--------|-----
-		if queryStart > sPos &&
-			queryStart < ePos &&
-			queryEnd > ePos {
-
-			overlappedPositions = true
-			overlap := ePos - queryStart
-			if overlap < 60 {
-				// fmt.Println("Adding right overlap")
-				*startPositions = append(*startPositions, queryStart)
-				*endPositions = append(*endPositions, queryEnd)
-				*goodResults = append(*goodResults, queryResult)
-			} else {
-				overlappedPositions = true
-			}
-
+	for _, r := range *goodResults {
+		if queryResult.Query.Location.Overlap(r.Query.Location) > maxOverlap {
+			return
 		}
+	}
 
-		// Case inside overlap
-		// -----|-------|--------
-		// ------|----|----------
-		if queryStart > sPos &&
-			queryStart < ePos &&
-			queryEnd > sPos &&
-			queryEnd < ePos {
-			overlappedPositions = true
-		}
+	*goodResults = append(*goodResults, queryResult)
 
-		// Case outside overlap
-		// -----|-------|--------
-		// ---|------------|-----
-		if queryStart < sPos &&
-			queryStart < ePos &&
-			queryEnd > sPos &&
-			queryEnd > ePos {
-			overlappedPositions = true
-		}
+}
 
+// Bounds returns the start and end position of the location on the plus
+// strand (start <= end)
+func (loc Location) Bounds() (int, int) {
+	if loc.PlusStrand {
+		return loc.StartPosition, loc.EndPosition
 	}
+	return loc.EndPosition, loc.StartPosition
+}
 
-	if !overlappedPositions {
-		// fmt.Println("Adding because no overlap")
-		*startPositions = append(*startPositions, queryStart)
-		*endPositions = append(*endPositions, queryEnd)
-		*goodResults = append(*goodResults, queryResult)
+// Overlap returns the number of bps shared by two locations, on any strand
+func (loc Location) Overlap(other Location) int {
+	start, end := loc.Bounds()
+	otherStart, otherEnd := other.Bounds()
+	if otherStart > start {
+		start = otherStart
 	}
-
+	if otherEnd < end {
+		end = otherEnd
+	}
+	if end < start {
+		return 0
+	}
+	return end - start + 1
 }
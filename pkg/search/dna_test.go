@@ -0,0 +1,48 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"testing"
+)
+
+func TestGetORFsNoEmptyORF(t *testing.T) {
+
+	for _, orfMode := range []string{ORF_START_STOP, ORF_STOP_STOP, ORF_SIX_FRAME} {
+		// frames ending on a stop codon and frames shorter than a codon
+		for _, dna := range []string{"ATGAAATAA", "AAATAA", "TAATAA", "AT", "ATGA"} {
+			orfs, err := GetORFs(dna, 11, orfMode, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, o := range orfs {
+				if o.Sequence == "" {
+					t.Errorf("%s ORF of %s is empty : %+v", orfMode, dna, o)
+				}
+			}
+		}
+	}
+
+}
+
+func TestGetORFsMinLength(t *testing.T) {
+
+	if _, err := GetORFs("ATGAAATAA", 11, ORF_STOP_STOP, 0); err == nil {
+		t.Error("minimum ORF length of 0 should be rejected")
+	}
+
+}
@@ -16,957 +16,278 @@ limitations under the License.
 
 package search
 
-// See https://www.bioinformatics.org/JaMBW/2/3/TranslationTables.html#SG15
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
 
-var GCodes = map[int]map[string]AminoAcid{
-	1:  gcode_1,
-	2:  gcode_2,
-	3:  gcode_3,
-	4:  gcode_4,
-	5:  gcode_5,
-	6:  gcode_6,
-	9:  gcode_9,
-	10: gcode_10,
-	11: gcode_11,
-	12: gcode_12,
-	13: gcode_13,
-	14: gcode_14,
-	15: gcode_15}
+// See https://www.ncbi.nlm.nih.gov/Taxonomy/Utils/wprintgc.cgi
+//
+// Tables are written in the NCBI compact format : one character per codon
+// in the order given by the Base1, Base2 and Base3 rows.
+// AAs has the translated amino acid ('*' for stop codons) and Starts has
+// 'M' for the initiation codons.
+// Context dependent stop codons (tables 27, 28 and 31) are translated with
+// their sense amino acid so they don't break ORFs.
 
-var gcodeBacteria = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ttg": AminoAcid{AA: "L", Start: true, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tga": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: true, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: true, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: true, Stop: false},
-	"ata": AminoAcid{AA: "I", Start: true, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: true, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
-}
+const (
+	GCODE_BASE1 = "TTTTTTTTTTTTTTTTCCCCCCCCCCCCCCCCAAAAAAAAAAAAAAAAGGGGGGGGGGGGGGGG"
+	GCODE_BASE2 = "TTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGG"
+	GCODE_BASE3 = "TCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAG"
+)
 
-var gcode_1 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tga": AminoAcid{AA: "*", Start: false, Stop: true},
-	"ttg": AminoAcid{AA: "L", Start: true, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: true, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: false, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: false, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "I", Start: false, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
+type GCodeTable struct {
+	Name   string
+	AAs    string
+	Starts string
+	Base1  string
+	Base2  string
+	Base3  string
 }
 
-var gcode_2 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tga": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ttg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: true, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: true, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "M", Start: true, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "*", Start: false, Stop: true},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "*", Start: false, Stop: true},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: true, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
+var NCBIGCodeTables = map[int]GCodeTable{
+	1: GCodeTable{
+		Name:   "Standard",
+		AAs:    "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "---M------**--*----M---------------M----------------------------"},
+	2: GCodeTable{
+		Name:   "Vertebrate Mitochondrial",
+		AAs:    "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG",
+		Starts: "----------**--------------------MMMM----------**---M------------"},
+	3: GCodeTable{
+		Name:   "Yeast Mitochondrial",
+		AAs:    "FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "----------**----------------------MM---------------M------------"},
+	4: GCodeTable{
+		Name:   "Mold, Protozoan, and Coelenterate Mitochondrial and the Mycoplasma/Spiroplasma",
+		AAs:    "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "--MM------**-------M------------MMMM---------------M------------"},
+	5: GCodeTable{
+		Name:   "Invertebrate Mitochondrial",
+		AAs:    "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG",
+		Starts: "---M------**--------------------MMMM---------------M------------"},
+	6: GCodeTable{
+		Name:   "Ciliate, Dasycladacean and Hexamita Nuclear",
+		AAs:    "FFLLSSSSYYQQCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "--------------*--------------------M----------------------------"},
+	9: GCodeTable{
+		Name:   "Echinoderm and Flatworm Mitochondrial",
+		AAs:    "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		Starts: "----------**-----------------------M---------------M------------"},
+	10: GCodeTable{
+		Name:   "Euplotid Nuclear",
+		AAs:    "FFLLSSSSYY**CCCWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "----------**-----------------------M----------------------------"},
+	11: GCodeTable{
+		Name:   "Bacterial, Archaeal and Plant Plastid",
+		AAs:    "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "---M------**--*----M------------MMMM---------------M------------"},
+	12: GCodeTable{
+		Name:   "Alternative Yeast Nuclear",
+		AAs:    "FFLLSSSSYY**CC*WLLLSPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "----------**--*----M---------------M----------------------------"},
+	13: GCodeTable{
+		Name:   "Ascidian Mitochondrial",
+		AAs:    "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSGGVVVVAAAADDEEGGGG",
+		Starts: "---M------**----------------------MM---------------M------------"},
+	14: GCodeTable{
+		Name:   "Alternative Flatworm Mitochondrial",
+		AAs:    "FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		Starts: "-----------*-----------------------M----------------------------"},
+	15: GCodeTable{
+		Name:   "Blepharisma Nuclear",
+		AAs:    "FFLLSSSSYY*QCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "----------*---*--------------------M----------------------------"},
+	16: GCodeTable{
+		Name:   "Chlorophycean Mitochondrial",
+		AAs:    "FFLLSSSSYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "----------*---*--------------------M----------------------------"},
+	21: GCodeTable{
+		Name:   "Trematode Mitochondrial",
+		AAs:    "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		Starts: "----------**-----------------------M---------------M------------"},
+	22: GCodeTable{
+		Name:   "Scenedesmus obliquus Mitochondrial",
+		AAs:    "FFLLSS*SYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "------*---*---*--------------------M----------------------------"},
+	23: GCodeTable{
+		Name:   "Thraustochytrium Mitochondrial",
+		AAs:    "FF*LSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "--*-------**--*-----------------M--M---------------M------------"},
+	24: GCodeTable{
+		Name:   "Rhabdopleuridae Mitochondrial",
+		AAs:    "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG",
+		Starts: "---M------**-------M---------------M---------------M------------"},
+	25: GCodeTable{
+		Name:   "Candidate Division SR1 and Gracilibacteria",
+		AAs:    "FFLLSSSSYY**CCGWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "---M------**-----------------------M---------------M------------"},
+	26: GCodeTable{
+		Name:   "Pachysolen tannophilus Nuclear",
+		AAs:    "FFLLSSSSYY**CC*WLLLAPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "----------**--*----M---------------M----------------------------"},
+	27: GCodeTable{
+		Name:   "Karyorelict Nuclear",
+		AAs:    "FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "--------------*--------------------M----------------------------"},
+	28: GCodeTable{
+		Name:   "Condylostoma Nuclear",
+		AAs:    "FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "----------**--*--------------------M----------------------------"},
+	29: GCodeTable{
+		Name:   "Mesodinium Nuclear",
+		AAs:    "FFLLSSSSYYYYCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "--------------*--------------------M----------------------------"},
+	30: GCodeTable{
+		Name:   "Peritrich Nuclear",
+		AAs:    "FFLLSSSSYYEECC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "--------------*--------------------M----------------------------"},
+	31: GCodeTable{
+		Name:   "Blastocrithidia Nuclear",
+		AAs:    "FFLLSSSSYYEECCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "----------**-----------------------M----------------------------"},
+	32: GCodeTable{
+		Name:   "Balanophoraceae Plastid",
+		AAs:    "FFLLSSSSYY*WCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		Starts: "---M------*---*----M------------MMMM---------------M------------"},
+	33: GCodeTable{
+		Name:   "Cephalodiscidae Mitochondrial UAA-Tyr",
+		AAs:    "FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG",
+		Starts: "---M-------*-------M---------------M---------------M------------"},
 }
 
-var gcode_3 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tga": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ttg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "T", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "T", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: false, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: false, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "M", Start: false, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
-}
+// GCodes are the codon translation tables by genetic code id
+var GCodes = map[int]map[string]AminoAcid{}
 
-var gcode_4 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: true, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tga": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ttg": AminoAcid{AA: "L", Start: true, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: true, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: true, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: true, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "I", Start: true, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: true, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
+func init() {
+	for id, table := range NCBIGCodeTables {
+		gcode, err := table.CodonTable()
+		if err != nil {
+			panic(fmt.Sprintf("Genetic code %d : %s", id, err.Error()))
+		}
+		GCodes[id] = gcode
+	}
 }
 
-var gcode_5 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tga": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ttg": AminoAcid{AA: "L", Start: true, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: true, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: true, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "M", Start: true, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: true, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
+// GetGeneticCode returns the codon translation table of an NCBI genetic code
+func GetGeneticCode(geneticCode int) (map[string]AminoAcid, error) {
+	gcode, ok := GCodes[geneticCode]
+	if !ok {
+		return nil, fmt.Errorf("Genetic code %d is not supported", geneticCode)
+	}
+	return gcode, nil
 }
 
-var gcode_6 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"tga": AminoAcid{AA: "*", Start: false, Stop: true},
-	"ttg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: false, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: false, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "I", Start: false, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
-}
+// CodonTable builds the codon translation table from the compact format
+func (t GCodeTable) CodonTable() (map[string]AminoAcid, error) {
 
-var gcode_9 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tga": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ttg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: false, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: false, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "I", Start: false, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "N", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
-}
+	base1, base2, base3 := t.Base1, t.Base2, t.Base3
+	if base1 == "" && base2 == "" && base3 == "" {
+		base1, base2, base3 = GCODE_BASE1, GCODE_BASE2, GCODE_BASE3
+	}
 
-var gcode_10 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tga": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: false, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: false, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "I", Start: false, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
-}
+	for _, row := range []string{t.AAs, t.Starts, base1, base2, base3} {
+		if len(row) != 64 {
+			return nil, errors.New("AAs, Starts and Base rows need 64 characters")
+		}
+	}
 
-var gcode_11 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tga": AminoAcid{AA: "*", Start: false, Stop: true},
-	"ttg": AminoAcid{AA: "L", Start: true, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: true, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: true, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: true, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "I", Start: true, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: true, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
-}
+	gcode := map[string]AminoAcid{}
 
-var gcode_12 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tga": AminoAcid{AA: "*", Start: false, Stop: true},
-	"ttg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "S", Start: true, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: false, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: false, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "I", Start: false, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
-}
+	for i := 0; i < 64; i++ {
+		codon := strings.ToLower(string([]byte{base1[i], base2[i], base3[i]}))
+		codon = strings.Replace(codon, "u", "t", -1)
+		if _, ok := gcode[codon]; ok {
+			return nil, fmt.Errorf("Codon %s is defined twice", codon)
+		}
+		gcode[codon] = AminoAcid{
+			AA:    string(t.AAs[i]),
+			Start: t.Starts[i] == 'M',
+			Stop:  t.AAs[i] == '*',
+		}
+	}
 
-var gcode_13 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tga": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ttg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: false, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: false, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "M", Start: false, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
-}
+	return gcode, nil
 
-var gcode_14 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tga": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ttg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: false, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: false, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "I", Start: false, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "N", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
 }
 
-var gcode_15 = map[string]AminoAcid{
-	"ttt": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tct": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tat": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgt": AminoAcid{AA: "C", Start: false, Stop: false},
-	"ttc": AminoAcid{AA: "F", Start: false, Stop: false},
-	"tcc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tac": AminoAcid{AA: "Y", Start: false, Stop: false},
-	"tgc": AminoAcid{AA: "C", Start: false, Stop: false},
-	"tta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tca": AminoAcid{AA: "S", Start: false, Stop: false},
-	"taa": AminoAcid{AA: "*", Start: false, Stop: true},
-	"tga": AminoAcid{AA: "*", Start: false, Stop: true},
-	"ttg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"tcg": AminoAcid{AA: "S", Start: false, Stop: false},
-	"tag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"tgg": AminoAcid{AA: "W", Start: false, Stop: false},
-	"ctt": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cct": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cat": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgt": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctc": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccc": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cac": AminoAcid{AA: "H", Start: false, Stop: false},
-	"cgc": AminoAcid{AA: "R", Start: false, Stop: false},
-	"cta": AminoAcid{AA: "L", Start: false, Stop: false},
-	"cca": AminoAcid{AA: "P", Start: false, Stop: false},
-	"caa": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"ctg": AminoAcid{AA: "L", Start: false, Stop: false},
-	"ccg": AminoAcid{AA: "P", Start: false, Stop: false},
-	"cag": AminoAcid{AA: "Q", Start: false, Stop: false},
-	"cgg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"att": AminoAcid{AA: "I", Start: false, Stop: false},
-	"act": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aat": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agt": AminoAcid{AA: "S", Start: false, Stop: false},
-	"atc": AminoAcid{AA: "I", Start: false, Stop: false},
-	"acc": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aac": AminoAcid{AA: "N", Start: false, Stop: false},
-	"agc": AminoAcid{AA: "S", Start: false, Stop: false},
-	"ata": AminoAcid{AA: "I", Start: false, Stop: false},
-	"aca": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aaa": AminoAcid{AA: "K", Start: false, Stop: false},
-	"aga": AminoAcid{AA: "R", Start: false, Stop: false},
-	"atg": AminoAcid{AA: "M", Start: true, Stop: false},
-	"acg": AminoAcid{AA: "T", Start: false, Stop: false},
-	"aag": AminoAcid{AA: "K", Start: false, Stop: false},
-	"agg": AminoAcid{AA: "R", Start: false, Stop: false},
-	"gtt": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gct": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gat": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggt": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtc": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcc": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gac": AminoAcid{AA: "D", Start: false, Stop: false},
-	"ggc": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gta": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gca": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gaa": AminoAcid{AA: "E", Start: false, Stop: false},
-	"gga": AminoAcid{AA: "G", Start: false, Stop: false},
-	"gtg": AminoAcid{AA: "V", Start: false, Stop: false},
-	"gcg": AminoAcid{AA: "A", Start: false, Stop: false},
-	"gag": AminoAcid{AA: "E", Start: false, Stop: false},
-	"ggg": AminoAcid{AA: "G", Start: false, Stop: false},
+// LoadGCodeFile adds a custom genetic code to GCodes from a file in the NCBI
+// print format (see https://www.ncbi.nlm.nih.gov/Taxonomy/Utils/wprintgc.cgi) :
+//
+//	id     = 101
+//	name   = My custom code
+//	AAs    = FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG
+//	Starts = ---M------**--*----M---------------M----------------------------
+//	Base1  = TTTTTTTTTTTTTTTTCCCCCCCCCCCCCCCCAAAAAAAAAAAAAAAAGGGGGGGGGGGGGGGG
+//	Base2  = TTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGGTTTTCCCCAAAAGGGG
+//	Base3  = TCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAGTCAG
+//
+// The Base rows are optional when in the NCBI order. The id can't be one of
+// the NCBI genetic codes.
+func LoadGCodeFile(fileName string) (int, error) {
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	id := 0
+	table := GCodeTable{}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		l := strings.TrimSpace(scanner.Text())
+		if l == "" || l[0] == '#' {
+			continue
+		}
+		kv := strings.SplitN(l, "=", 2)
+		if len(kv) != 2 {
+			return 0, fmt.Errorf("Invalid genetic code line : %s", l)
+		}
+		value := strings.TrimSpace(kv[1])
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "id":
+			if id, err = strconv.Atoi(value); err != nil || id < 1 {
+				return 0, fmt.Errorf("Invalid genetic code id : %s", value)
+			}
+		case "name":
+			table.Name = value
+		case "aas":
+			table.AAs = strings.ToUpper(value)
+		case "starts":
+			table.Starts = strings.ToUpper(value)
+		case "base1":
+			table.Base1 = strings.ToUpper(value)
+		case "base2":
+			table.Base2 = strings.ToUpper(value)
+		case "base3":
+			table.Base3 = strings.ToUpper(value)
+		default:
+			return 0, fmt.Errorf("Invalid genetic code line : %s", l)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	if id == 0 {
+		return 0, errors.New("Genetic code file needs an id")
+	}
+	if _, ok := NCBIGCodeTables[id]; ok {
+		return 0, fmt.Errorf("Genetic code id %d is already an NCBI genetic code", id)
+	}
+
+	gcode, err := table.CodonTable()
+	if err != nil {
+		return 0, err
+	}
+	GCodes[id] = gcode
+
+	return id, nil
+
 }
@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"math"
+
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+const (
+	IDF_WEIGHT_SCALE = 100 // weights are counted in hundredths of a kmer
+)
+
+// KmerWeight returns the scaled weight (0 to IDF_WEIGHT_SCALE) of a kmer
+// from the number of database proteins sharing it (df) : log(N/df) / log(N).
+// A kmer found in a single protein weighs a whole kmer match and one found
+// in every protein weighs nothing.
+// df is the KComb.Count set at index time, which is len(ProteinKeys) (read
+// directly for databases indexed before it was set).
+func KmerWeight(kComb *kvstore.KComb, nbOfProteins uint64) int {
+
+	df := float64(kComb.Count)
+	if df < 1 {
+		df = float64(len(kComb.ProteinKeys))
+	}
+	n := float64(nbOfProteins)
+	if n < 2 || df < 1 {
+		return IDF_WEIGHT_SCALE
+	}
+	if df > n {
+		df = n
+	}
+
+	return int(math.Round(IDF_WEIGHT_SCALE * math.Log(n/df) / math.Log(n)))
+
+}
+
+// UnscaleWeights converts the scaled weighted kmer matches of the hits
+// (see KmerWeight) to kmer matches, hits stay ranked by their scaled score
+func (p HitList) UnscaleWeights() {
+
+	for i := range p {
+		p[i].Kmatch = (p[i].Kmatch + IDF_WEIGHT_SCALE/2) / IDF_WEIGHT_SCALE
+	}
+
+}
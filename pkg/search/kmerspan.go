@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+// KmerSpan is the region of a query and of a hit covered by their shared
+// kmers (1-based amino acid positions) for the hits without alignment
+type KmerSpan struct {
+	QueryStart   int
+	QueryEnd     int
+	SubjectStart int
+	SubjectEnd   int
+}
+
+// SetKmerSpans sets the KmerSpan of the hits from an exact lookup of the
+// query kmers, indexed with the database scheme, in the hit sequences.
+// Hits without a shared kmer in their sequence keep a nil KmerSpan.
+func (queryResult *QueryResult) SetKmerSpans(scheme *kvstore.KScheme) {
+
+	querySeq := queryResult.Query.Sequence
+	kmerSpan := scheme.Span()
+	if len(querySeq) < kmerSpan {
+		return
+	}
+
+	queryKmers := kmerPositions(querySeq, scheme)
+
+	for i, h := range queryResult.SearchResults.Hits {
+
+		hitSeq := queryResult.HitEntries[h.Key].Sequence
+		var span *KmerSpan
+
+		for j := 0; j+kmerSpan <= len(hitSeq); j++ {
+			queryPositions, ok := queryKmers[scheme.Kmer(hitSeq[j:j+kmerSpan])]
+			if !ok {
+				continue
+			}
+			if span == nil {
+				span = &KmerSpan{QueryStart: len(querySeq), SubjectStart: j + 1}
+			}
+			span.SubjectEnd = j + kmerSpan
+			for _, p := range queryPositions {
+				if p+1 < span.QueryStart {
+					span.QueryStart = p + 1
+				}
+				if p+kmerSpan > span.QueryEnd {
+					span.QueryEnd = p + kmerSpan
+				}
+			}
+		}
+
+		queryResult.SearchResults.Hits[i].KmerSpan = span
+
+	}
+
+}
+
+// kmerPositions returns the positions of the windows of seq by indexed kmer
+func kmerPositions(seq string, scheme *kvstore.KScheme) map[string][]int {
+
+	kmerSpan := scheme.Span()
+	kmers := map[string][]int{}
+	for i := 0; i+kmerSpan <= len(seq); i++ {
+		kmer := scheme.Kmer(seq[i : i+kmerSpan])
+		kmers[kmer] = append(kmers[kmer], i)
+	}
+
+	return kmers
+
+}
+
+// Span returns the query and subject positions of a hit without alignment,
+// its KmerSpan or the whole query and subject if unknown
+func (queryResult *QueryResult) Span(h Hit) (int, int, int, int) {
+	if h.KmerSpan != nil {
+		return h.KmerSpan.QueryStart, h.KmerSpan.QueryEnd, h.KmerSpan.SubjectStart, h.KmerSpan.SubjectEnd
+	}
+	return 1, len(queryResult.Query.Sequence), 1, int(queryResult.HitEntries[h.Key].Length)
+}
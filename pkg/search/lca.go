@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"strings"
+
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+const (
+	DEFAULT_LCA_WINDOW = 10.0 // % of the best hit score
+)
+
+// LCA is the lowest common ancestor of the best hits of a query (or read)
+type LCA struct {
+	Taxon   string
+	TaxId   string // only when all the hits share the same taxon id
+	Lineage []string
+	NbHits  int
+}
+
+// GetLCA returns the lowest common ancestor of the hits of queryResults that
+// score within window % of the best hit (bit score if aligned, kmer matches
+// otherwise), or nil if none of these hits has a taxonomy.
+func GetLCA(queryResults []QueryResult, window float64, aligned bool) *LCA {
+
+	bestScore := 0.0
+	for _, qR := range queryResults {
+		for _, h := range qR.SearchResults.Hits {
+			if score := h.Score(aligned); score > bestScore {
+				bestScore = score
+			}
+		}
+	}
+	minScore := bestScore * (1 - window/100)
+
+	var lca *LCA
+	for _, qR := range queryResults {
+		for _, h := range qR.SearchResults.Hits {
+			if h.Score(aligned) < minScore {
+				continue
+			}
+			hit := qR.HitEntries[h.Key]
+			lineage := GetLineage(hit)
+			if len(lineage) == 0 {
+				continue
+			}
+			if lca == nil {
+				lca = &LCA{Lineage: lineage, TaxId: hit.Features["TaxId"]}
+			} else {
+				i := 0
+				for i < len(lca.Lineage) && i < len(lineage) && lca.Lineage[i] == lineage[i] {
+					i++
+				}
+				lca.Lineage = lca.Lineage[:i]
+				if lca.TaxId != hit.Features["TaxId"] {
+					lca.TaxId = ""
+				}
+			}
+			lca.NbHits++
+		}
+	}
+
+	if lca == nil {
+		return nil
+	}
+
+	if len(lca.Lineage) == 0 {
+		lca.Taxon = "root"
+	} else {
+		lca.Taxon = lca.Lineage[len(lca.Lineage)-1]
+	}
+
+	return lca
+
+}
+
+// GetLineage returns the taxonomic lineage of a protein from its
+// FullTaxonomy feature (semicolon separated) followed by its Organism
+func GetLineage(protein kvstore.Protein) []string {
+
+	lineage := []string{}
+	for _, taxon := range strings.Split(protein.Features["FullTaxonomy"], ";") {
+		taxon = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(taxon), "."))
+		if taxon != "" {
+			lineage = append(lineage, taxon)
+		}
+	}
+
+	organism := strings.TrimSpace(strings.TrimSuffix(protein.Features["Organism"], "."))
+	if organism != "" && (len(lineage) == 0 || lineage[len(lineage)-1] != organism) {
+		lineage = append(lineage, organism)
+	}
+
+	return lineage
+
+}
+
+// FormatLCAColumns returns the tsv LCA columns (taxon, tax id and lineage)
+func FormatLCAColumns(lca *LCA) string {
+	if lca == nil {
+		return "\tN/A\tN/A\tN/A"
+	}
+	return "\t" + lca.Taxon + "\t" + naIfEmpty(lca.TaxId) + "\t" + naIfEmpty(strings.Join(lca.Lineage, "; "))
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	MATRIX_PROTEIN = "protein"
+	MATRIX_TAXON   = "taxon"
+
+	// MATRIX_TIMEOUT_HEADER lists the samples with partial counts (search timed out)
+	MATRIX_TIMEOUT_HEADER = "X-Kaamer-Timed-Out-Samples"
+)
+
+// Sample is a read file of a multi-sample search
+type Sample struct {
+	Name string
+	File string
+}
+
+// ValidMatrixFeature returns true if feature can be counted in an abundance
+// matrix : MATRIX_PROTEIN, MATRIX_TAXON or one of the SummaryFeatures
+func ValidMatrixFeature(feature string) bool {
+	if feature == MATRIX_PROTEIN || feature == MATRIX_TAXON {
+		return true
+	}
+	for _, f := range SummaryFeatures {
+		if feature == f {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts returns the number of reads of each protein, taxon or annotation
+// value of feature
+func (a *Abundance) Counts(feature string) map[string]int64 {
+
+	abundanceCounts := a.count()
+	counts := abundanceCounts.features[feature]
+	switch feature {
+	case MATRIX_PROTEIN:
+		counts = abundanceCounts.proteins
+	case MATRIX_TAXON:
+		counts = abundanceCounts.taxa
+	}
+
+	readCounts := map[string]int64{}
+	for id, c := range counts {
+		readCounts[id] = c.Reads
+	}
+
+	return readCounts
+
+}
+
+// NewMatrixResult runs the reads search of every sample, one after the other,
+// and writes the feature by sample read count matrix to w.
+// searchOptions.Timeout applies to each sample search.
+func NewMatrixResult(searchOptions SearchOptions, samples []Sample, feature string, searcher *Searcher, w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	sampleCounts := []map[string]int64{}
+	timedOutSamples := []string{}
+	var sampleErr error
+
+	for _, sample := range samples {
+
+		sampleOptions := searchOptions
+		sampleOptions.File = sample.File
+
+		abundance := NewAbundance()
+		err := searcher.SearchFunc(ctx, sampleOptions, abundance.Add)
+		if ctx.Err() != nil {
+			break
+		}
+		if err == context.DeadlineExceeded {
+			timedOutSamples = append(timedOutSamples, sample.Name)
+		} else if err != nil {
+			sampleErr = fmt.Errorf("Sample %s : %s", sample.Name, err.Error())
+			break
+		}
+
+		sampleCounts = append(sampleCounts, abundance.Counts(feature))
+
+	}
+
+	if searchOptions.InputType != "path" {
+		for _, sample := range samples {
+			os.Remove(sample.File)
+		}
+	}
+
+	// client is gone
+	if ctx.Err() != nil {
+		return
+	}
+
+	if sampleErr != nil {
+		w.WriteHeader(400)
+		fmt.Fprintln(w, sampleErr.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain;charset=UTF-8")
+	if len(timedOutSamples) > 0 {
+		w.Header().Set(MATRIX_TIMEOUT_HEADER, strings.Join(timedOutSamples, ","))
+	}
+	w.WriteHeader(200)
+
+	names := []string{}
+	for _, sample := range samples {
+		names = append(names, sample.Name)
+	}
+	w.Write([]byte(FormatAbundanceMatrix(feature, names, sampleCounts)))
+
+}
+
+// FormatAbundanceMatrix returns the tsv count matrix of the samples with one
+// row per feature id (sorted) and one column per sample.
+// Counts are raw read counts (0 if absent) so the matrix can be given as is
+// to count-based differential abundance tools.
+func FormatAbundanceMatrix(feature string, sampleNames []string, sampleCounts []map[string]int64) string {
+
+	idSet := map[string]bool{}
+	for _, counts := range sampleCounts {
+		for id := range counts {
+			idSet[id] = true
+		}
+	}
+	ids := []string{}
+	for id := range idSet {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	output := feature + "\t" + strings.Join(sampleNames, "\t") + "\n"
+
+	for _, id := range ids {
+		output += id
+		for _, counts := range sampleCounts {
+			output += "\t" + strconv.FormatInt(counts[id], 10)
+		}
+		output += "\n"
+	}
+
+	return output
+
+}
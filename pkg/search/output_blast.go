@@ -0,0 +1,204 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	BLAST_DEFAULT_COLUMNS = "qseqid sseqid pident length mismatch gapopen qstart qend sstart send evalue bitscore"
+)
+
+var (
+	// BlastFormats are the BLAST tabular output formats (outfmt 6 and 7)
+	BlastFormats = map[string]bool{"blast6": true, "blast7": true}
+
+	// BlastColumns are the supported BLAST tabular columns with their
+	// description (blast7 Fields line)
+	BlastColumns = map[string]string{
+		"qseqid":   "query id",
+		"sseqid":   "subject id",
+		"pident":   "% identity",
+		"length":   "alignment length",
+		"mismatch": "mismatches",
+		"gapopen":  "gap opens",
+		"qstart":   "q. start",
+		"qend":     "q. end",
+		"sstart":   "s. start",
+		"send":     "s. end",
+		"evalue":   "evalue",
+		"bitscore": "bit score",
+		"score":    "score",
+		"nident":   "identical",
+		"ppos":     "% positives",
+		"qlen":     "query length",
+		"slen":     "subject length",
+		"qcovhsp":  "% query coverage per hsp",
+		"scovhsp":  "% subject coverage per hsp",
+		"staxid":   "subject tax id",
+		"staxids":  "subject tax ids",
+		"stitle":   "subject title",
+	}
+)
+
+// ParseBlastColumns returns the columns of a space or comma separated
+// column list (BLAST_DEFAULT_COLUMNS if empty)
+func ParseBlastColumns(columns string) ([]string, error) {
+
+	fields := strings.FieldsFunc(strings.ToLower(columns), func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	if len(fields) == 0 {
+		fields = strings.Fields(BLAST_DEFAULT_COLUMNS)
+	}
+
+	for _, f := range fields {
+		// a hit has a single HSP, there is no coverage across the HSPs of a subject
+		if f == "qcovs" || f == "scovs" {
+			return nil, fmt.Errorf("Output column %s (coverage per subject) is not supported, use %shsp", f, f[:4])
+		}
+		if _, ok := BlastColumns[f]; !ok {
+			return nil, fmt.Errorf("Output column %s is not supported", f)
+		}
+	}
+
+	return fields, nil
+
+}
+
+// FormatBlastComments returns the blast7 comment lines preceding the hits of a query
+func FormatBlastComments(qR QueryResult, searchOptions SearchOptions) string {
+
+	fields := []string{}
+	for _, c := range searchOptions.OutColumns {
+		fields = append(fields, BlastColumns[c])
+	}
+
+	output := ""
+	output += "# kAAmer\n"
+	output += "# Query: " + qR.Query.Name + "\n"
+	output += "# Fields: " + strings.Join(fields, ", ") + "\n"
+	output += fmt.Sprintf("# %d hits found\n", len(qR.SearchResults.Hits))
+
+	return output
+
+}
+
+// FormatBlastTab returns the BLAST tabular lines (outfmt 6) of the hits of
+// a query with the searchOptions.OutColumns.
+// Nucleotide query positions are reported on the query contig (or read).
+func FormatBlastTab(qR QueryResult, searchOptions SearchOptions) string {
+
+	output := ""
+	querySeq := strings.TrimSuffix(qR.Query.Sequence, "*")
+
+	for _, h := range qR.SearchResults.Hits {
+
+		hit := qR.HitEntries[h.Key]
+		aln := h.Alignment
+		qStart, qEnd := aln.QueryStart, aln.QueryEnd
+		if searchOptions.SequenceType != PROTEIN {
+			qStart, qEnd = proteinToNucleotidePositions(qR.Query.Location, aln.QueryStart, aln.QueryEnd)
+		}
+
+		values := []string{}
+		for _, c := range searchOptions.OutColumns {
+			switch c {
+			case "qseqid":
+				values = append(values, strings.Split(qR.Query.Name, " ")[0])
+			case "sseqid":
+				values = append(values, hit.EntryId)
+			case "pident":
+				values = append(values, fmt.Sprintf("%.2f", aln.Identity))
+			case "length":
+				values = append(values, strconv.Itoa(aln.Length))
+			case "mismatch":
+				values = append(values, strconv.Itoa(aln.Mismatches))
+			case "gapopen":
+				values = append(values, strconv.Itoa(aln.GapOpenings))
+			case "qstart":
+				values = append(values, strconv.Itoa(qStart))
+			case "qend":
+				values = append(values, strconv.Itoa(qEnd))
+			case "sstart":
+				values = append(values, strconv.Itoa(aln.SubjectStart))
+			case "send":
+				values = append(values, strconv.Itoa(aln.SubjectEnd))
+			case "evalue":
+				values = append(values, fmt.Sprintf("%.2e", aln.EValue))
+			case "bitscore":
+				values = append(values, fmt.Sprintf("%.1f", aln.BitScore))
+			case "score":
+				values = append(values, strconv.Itoa(aln.Raw))
+			case "nident":
+				values = append(values, strconv.Itoa(int(float32(aln.Length)*aln.Identity/100+0.5)))
+			case "ppos":
+				values = append(values, fmt.Sprintf("%.2f", aln.Similarity))
+			case "qlen":
+				values = append(values, strconv.Itoa(len(querySeq)))
+			case "slen":
+				values = append(values, strconv.Itoa(int(hit.Length)))
+			case "qcovhsp":
+				values = append(values, coverage(aln.QueryStart, aln.QueryEnd, len(querySeq)))
+			case "scovhsp":
+				values = append(values, coverage(aln.SubjectStart, aln.SubjectEnd, int(hit.Length)))
+			case "staxid", "staxids":
+				values = append(values, naIfEmpty(hit.Features["TaxId"]))
+			case "stitle":
+				values = append(values, naIfEmpty(strings.TrimSpace(hit.EntryId+" "+hit.Features["ProteinName"])))
+			}
+		}
+
+		output += strings.Join(values, "\t")
+		output += "\n"
+
+	}
+
+	return output
+
+}
+
+// proteinToNucleotidePositions returns the nucleotide positions of an ORF
+// segment given by its amino acid positions (1-based)
+func proteinToNucleotidePositions(loc Location, aaStart int, aaEnd int) (int, int) {
+	if loc.PlusStrand {
+		return loc.StartPosition + (aaStart-1)*3, loc.StartPosition + aaEnd*3 - 1
+	}
+	return loc.StartPosition - (aaStart-1)*3, loc.StartPosition - aaEnd*3 + 1
+}
+
+func coverage(start int, end int, length int) string {
+	return strconv.Itoa(int(coveragePercent(start, end, length)))
+}
+
+// coveragePercent returns the % of a sequence of length covered by start..end
+func coveragePercent(start int, end int, length int) float64 {
+	if length < 1 {
+		return 0
+	}
+	return float64(end-start+1) / float64(length) * 100
+}
+
+func naIfEmpty(value string) string {
+	if value == "" {
+		return "N/A"
+	}
+	return value
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	FASTA_LINE_WIDTH = 60
+)
+
+// ORFId returns the id of an ORF query : <query id>_<start>_<end>_<strand>
+func ORFId(q Query) string {
+	start, end := q.Location.Bounds()
+	strand := "+"
+	if !q.Location.PlusStrand {
+		strand = "-"
+	}
+	return fmt.Sprintf("%s_%d_%d_%s", strings.Split(q.Name, " ")[0], start, end, strand)
+}
+
+// FormatFasta returns the translated ORF of a query result as protein fasta
+// followed by the sequence of its hits if searchOptions.HitSequences
+func FormatFasta(qR QueryResult, searchOptions SearchOptions) string {
+
+	start, end := qR.Query.Location.Bounds()
+	strand := "+"
+	if !qR.Query.Location.PlusStrand {
+		strand = "-"
+	}
+	orfId := ORFId(qR.Query)
+
+	output := ""
+	output += fmt.Sprintf(">%s location=%d..%d strand=%s frame=%d query=%s", orfId, start, end, strand, qR.Query.Location.Frame, strings.Split(qR.Query.Name, " ")[0])
+	if len(qR.SearchResults.Hits) > 0 {
+		output += " best_hit=" + qR.HitEntries[qR.SearchResults.Hits[0].Key].EntryId
+	}
+	output += "\n"
+	output += wrapSequence(strings.TrimSuffix(qR.Query.Sequence, "*"))
+
+	if searchOptions.HitSequences {
+		for _, h := range qR.SearchResults.Hits {
+			hit := qR.HitEntries[h.Key]
+			output += ">" + hit.EntryId
+			if name := strings.Split(hit.Features["ProteinName"], ";;")[0]; name != "" {
+				output += " " + name
+			}
+			output += " orf=" + orfId
+			output += "\n"
+			output += wrapSequence(hit.Sequence)
+		}
+	}
+
+	return output
+
+}
+
+func wrapSequence(seq string) string {
+	output := ""
+	for i := 0; i < len(seq); i += FASTA_LINE_WIDTH {
+		output += seq[i:minInt(i+FASTA_LINE_WIDTH, len(seq))] + "\n"
+	}
+	return output
+}
@@ -0,0 +1,371 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+const (
+	FLATFILE_LINE_WIDTH     = 80
+	FLATFILE_QUALIFIER_COL  = 21
+	FLATFILE_SEQ_LINE_BASES = 60
+)
+
+var (
+	// RecordFormats are the output formats that write the query contigs back
+	// as annotated flat file records
+	RecordFormats = map[string]bool{"genbank": true, "embl": true}
+
+	ecEvidence = regexp.MustCompile(`\s*\{.*?\}`)
+)
+
+// ContigRecords holds the ORF results of each query contig (by name) until
+// the search is done and the annotated contig records can be written
+type ContigRecords struct {
+	ORFs map[string][]QueryResult
+}
+
+type recordFeature struct {
+	Key        string
+	Location   string
+	Qualifiers [][2]string
+}
+
+func NewContigRecords() *ContigRecords {
+	return &ContigRecords{ORFs: map[string][]QueryResult{}}
+}
+
+// Collect stores the query results received until queryResult is closed
+func (c *ContigRecords) Collect(ctx context.Context, queryResult <-chan QueryResult, wg *sync.WaitGroup) {
+
+	defer wg.Done()
+
+	for qR := range queryResult {
+		// client is gone, only drain the results
+		if ctx.Err() != nil {
+			continue
+		}
+		c.ORFs[qR.Query.Contig] = append(c.ORFs[qR.Query.Contig], qR)
+	}
+
+}
+
+// WriteRecords sends to queryWriter the annotated record of every contig
+// of the query file, in the input order
+func (c *ContigRecords) WriteRecords(ctx context.Context, queryWriter chan<- []byte, searchOptions SearchOptions, timedOut bool) {
+
+	input, err := OpenQueries(searchOptions.File)
+	if err != nil {
+		return
+	}
+	defer input.Close()
+
+	queryChan := make(chan Query)
+
+	go func() {
+		// only the contig sequences are used, not their size in kmers
+		GetQueriesFasta(ctx, input, queryChan, false, kvstore.DEFAULT_KMER_SIZE)
+		close(queryChan)
+	}()
+
+	for q := range queryChan {
+
+		orfs := c.ORFs[q.Contig]
+		sort.SliceStable(orfs, func(i, j int) bool {
+			iStart, _ := orfs[i].Query.Location.Bounds()
+			jStart, _ := orfs[j].Query.Location.Bounds()
+			return iStart < jStart
+		})
+
+		if searchOptions.OutFormat == "embl" {
+			queryWriter <- []byte(FormatEMBL(q, orfs, searchOptions, timedOut))
+		} else {
+			queryWriter <- []byte(FormatGenBank(q, orfs, searchOptions, timedOut))
+		}
+
+	}
+
+}
+
+// FormatGenBank returns the GenBank record of a contig with its ORFs as CDS features
+func FormatGenBank(contig Query, orfs []QueryResult, searchOptions SearchOptions, timedOut bool) string {
+
+	id, definition := recordIdAndDefinition(contig)
+
+	output := ""
+	output += fmt.Sprintf("LOCUS       %-16s %11d bp    DNA     linear   UNK %s\n", id, len(contig.Sequence), strings.ToUpper(time.Now().Format("02-Jan-2006")))
+	output += "DEFINITION  " + definition + "\n"
+	output += "ACCESSION   " + id + "\n"
+	if timedOut {
+		output += "COMMENT     search timed out, annotations are partial\n"
+	}
+	output += "FEATURES             Location/Qualifiers\n"
+	for _, f := range recordFeatures(contig, orfs, searchOptions) {
+		output += formatFeature(f, "     ", "")
+	}
+
+	output += "ORIGIN\n"
+	seq := strings.ToLower(contig.Sequence)
+	for i := 0; i < len(seq); i += FLATFILE_SEQ_LINE_BASES {
+		output += fmt.Sprintf("%9d", i+1)
+		for j := i; j < i+FLATFILE_SEQ_LINE_BASES && j < len(seq); j += 10 {
+			output += " " + seq[j:minInt(j+10, len(seq))]
+		}
+		output += "\n"
+	}
+	output += "//\n"
+
+	return output
+
+}
+
+// FormatEMBL returns the EMBL record of a contig with its ORFs as CDS features
+func FormatEMBL(contig Query, orfs []QueryResult, searchOptions SearchOptions, timedOut bool) string {
+
+	id, definition := recordIdAndDefinition(contig)
+
+	output := ""
+	output += fmt.Sprintf("ID   %s; SV 1; linear; genomic DNA; STD; UNC; %d BP.\n", id, len(contig.Sequence))
+	output += "XX\n"
+	output += "AC   " + id + ";\n"
+	output += "XX\n"
+	output += "DE   " + definition + "\n"
+	output += "XX\n"
+	if timedOut {
+		output += "CC   search timed out, annotations are partial\n"
+		output += "XX\n"
+	}
+	output += "FH   Key             Location/Qualifiers\n"
+	output += "FH\n"
+	for _, f := range recordFeatures(contig, orfs, searchOptions) {
+		output += formatFeature(f, "FT   ", "FT")
+	}
+	output += "XX\n"
+
+	seq := strings.ToLower(contig.Sequence)
+	counts := map[rune]int{}
+	for _, b := range seq {
+		counts[b]++
+	}
+	other := len(seq) - counts['a'] - counts['c'] - counts['g'] - counts['t']
+	output += fmt.Sprintf("SQ   Sequence %d BP; %d A; %d C; %d G; %d T; %d other;\n", len(seq), counts['a'], counts['c'], counts['g'], counts['t'], other)
+	for i := 0; i < len(seq); i += FLATFILE_SEQ_LINE_BASES {
+		line := ""
+		for j := i; j < i+FLATFILE_SEQ_LINE_BASES && j < len(seq); j += 10 {
+			if line != "" {
+				line += " "
+			}
+			line += seq[j:minInt(j+10, len(seq))]
+		}
+		output += fmt.Sprintf("     %-65s%10d\n", line, minInt(i+FLATFILE_SEQ_LINE_BASES, len(seq)))
+	}
+	output += "//\n"
+
+	return output
+
+}
+
+// recordFeatures returns the source feature of a contig followed by the
+// CDS feature of each ORF annotated with its best hit
+func recordFeatures(contig Query, orfs []QueryResult, searchOptions SearchOptions) []recordFeature {
+
+	id, _ := recordIdAndDefinition(contig)
+
+	features := []recordFeature{
+		{
+			Key:        "source",
+			Location:   fmt.Sprintf("1..%d", len(contig.Sequence)),
+			Qualifiers: [][2]string{{"mol_type", quoteQualifier("genomic DNA")}},
+		},
+	}
+
+	for i, qR := range orfs {
+
+		if len(qR.SearchResults.Hits) == 0 {
+			continue
+		}
+		hit := qR.HitEntries[qR.SearchResults.Hits[0].Key]
+
+		location, codonStart := cdsLocation(contig, qR.Query, searchOptions.GeneticCode)
+
+		product := strings.Split(hit.Features["ProteinName"], ";;")[0]
+		product = strings.TrimSpace(ecEvidence.ReplaceAllString(product, ""))
+		if product == "" {
+			product = "hypothetical protein"
+		}
+
+		qualifiers := [][2]string{
+			{"locus_tag", quoteQualifier(fmt.Sprintf("%s_%05d", id, i+1))},
+			{"product", quoteQualifier(product)},
+		}
+		for _, ec := range strings.Split(hit.Features["EC"], ";") {
+			if ec = strings.TrimSpace(ecEvidence.ReplaceAllString(ec, "")); ec != "" {
+				qualifiers = append(qualifiers, [2]string{"EC_number", quoteQualifier(ec)})
+			}
+		}
+		qualifiers = append(qualifiers, [2]string{"inference", quoteQualifier("similar to AA sequence:UniProtKB:" + hit.EntryId)})
+		for _, keggId := range strings.Split(hit.Features["KEGG_ID"], ";") {
+			if keggId = strings.TrimSpace(keggId); keggId != "" {
+				qualifiers = append(qualifiers, [2]string{"db_xref", quoteQualifier("KEGG:" + keggId)})
+			}
+		}
+		for _, goId := range strings.Split(hit.Features["GO"], ";") {
+			if goId = strings.TrimSpace(goId); goId != "" {
+				qualifiers = append(qualifiers, [2]string{"db_xref", quoteQualifier(goId)})
+			}
+		}
+		qualifiers = append(qualifiers, [2]string{"codon_start", strconv.Itoa(codonStart)})
+		// only the NCBI genetic codes are INSDC translation tables
+		if _, ok := NCBIGCodeTables[searchOptions.GeneticCode]; ok {
+			qualifiers = append(qualifiers, [2]string{"transl_table", strconv.Itoa(searchOptions.GeneticCode)})
+		} else {
+			qualifiers = append(qualifiers, [2]string{"note", quoteQualifier(fmt.Sprintf("translated with custom genetic code %d", searchOptions.GeneticCode))})
+		}
+		qualifiers = append(qualifiers, [2]string{"translation", quoteQualifier(strings.TrimSuffix(qR.Query.Sequence, "*"))})
+
+		features = append(features, recordFeature{Key: "CDS", Location: location, Qualifiers: qualifiers})
+
+	}
+
+	return features
+
+}
+
+// cdsLocation returns the feature location of an ORF and its codon_start.
+// ORFs running off the contig edge are partial : an ORF starting at the first
+// codon of its frame without a start codon is extended to the contig edge
+// with a < (> on the minus strand) and codon_start is its frame offset, an
+// ORF without a stop codon is extended to the other edge with a >
+// (< on the minus strand).
+func cdsLocation(contig Query, orf Query, geneticCode int) (string, int) {
+
+	contigLength := len(contig.Sequence)
+	start, end := orf.Location.Bounds()
+	codonStart := 1
+
+	// 5' end, on the first codon of the frame
+	partial5 := false
+	offset := start - 1
+	codon := ""
+	if orf.Location.PlusStrand && offset < 3 && start+2 <= contigLength {
+		codon = contig.Sequence[start-1 : start+2]
+	} else if !orf.Location.PlusStrand {
+		offset = contigLength - end
+		if offset < 3 && end-3 >= 0 {
+			codon = ReverseComplement(contig.Sequence[end-3 : end])
+		}
+	}
+	if codon != "" {
+		gcode, _ := GetGeneticCode(geneticCode)
+		if aa, ok := gcode[strings.ToLower(codon)]; !ok || !aa.Start {
+			partial5 = true
+			codonStart = offset + 1
+		}
+	}
+
+	// 3' end, no stop codon
+	partial3 := !strings.HasSuffix(orf.Sequence, "*")
+
+	startMark, endMark := "", ""
+	if orf.Location.PlusStrand {
+		if partial5 {
+			start, startMark = 1, "<"
+		}
+		if partial3 {
+			end, endMark = contigLength, ">"
+		}
+	} else {
+		if partial5 {
+			end, endMark = contigLength, ">"
+		}
+		if partial3 {
+			start, startMark = 1, "<"
+		}
+	}
+
+	location := fmt.Sprintf("%s%d..%s%d", startMark, start, endMark, end)
+	if !orf.Location.PlusStrand {
+		location = "complement(" + location + ")"
+	}
+
+	return location, codonStart
+
+}
+
+// formatFeature returns the feature table lines of a feature, with its
+// qualifiers wrapped at column FLATFILE_LINE_WIDTH.
+// Lines start with keyPrefix for the feature key and with linePrefix
+// (padded up to the qualifier column) for the next lines.
+func formatFeature(feature recordFeature, keyPrefix string, linePrefix string) string {
+
+	indent := linePrefix + strings.Repeat(" ", FLATFILE_QUALIFIER_COL-len(linePrefix))
+	width := FLATFILE_LINE_WIDTH - FLATFILE_QUALIFIER_COL
+
+	output := fmt.Sprintf("%s%-16s%s\n", keyPrefix, feature.Key, feature.Location)
+
+	for _, q := range feature.Qualifiers {
+		text := "/" + q[0] + "=" + q[1]
+		for len(text) > width {
+			cut := strings.LastIndexByte(text[:width], ' ')
+			if cut <= 0 {
+				output += indent + text[:width] + "\n"
+				text = text[width:]
+			} else {
+				output += indent + text[:cut] + "\n"
+				text = text[cut+1:]
+			}
+		}
+		output += indent + text + "\n"
+	}
+
+	return output
+
+}
+
+// recordIdAndDefinition splits a contig fasta header into its id and description
+func recordIdAndDefinition(contig Query) (string, string) {
+
+	fields := strings.SplitN(strings.TrimSpace(contig.Name), " ", 2)
+	definition := "."
+	if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+		definition = strings.TrimSpace(fields[1])
+	}
+
+	return fields[0], definition
+
+}
+
+func quoteQualifier(value string) string {
+	return "\"" + strings.Replace(value, "\"", "\"\"", -1) + "\""
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"testing"
+)
+
+func TestCDSLocationPartial(t *testing.T) {
+
+	tests := []struct {
+		contig     string
+		frame      int
+		location   string
+		codonStart int
+	}{
+		// frame 2 from the contig edge (no start codon) to a stop codon
+		{"AGCTGCTGCTTAAG", 2, "<1..13", 2},
+		// start codon on the first codon, no stop codon
+		{"ATGGCTGCTGCTGC", 1, "1..>14", 1},
+		// complete ORF inside the contig
+		{"CCATGGCTGCTTAACC", 3, "3..14", 1},
+		// minus strand from the contig edge (no start codon) to a stop codon
+		{ReverseComplement("AGCTGCTGCTTAAG"), -2, "complement(2..>14)", 2},
+		// minus strand from a start codon without stop codon
+		{ReverseComplement("ATGGCTGCTGCTGC"), -1, "complement(<1..14)", 1},
+	}
+
+	for _, test := range tests {
+		orfs, err := GetORFs(test.contig, 11, ORF_START_STOP, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, o := range orfs {
+			if o.Location.Frame != test.frame {
+				continue
+			}
+			found = true
+			orf := Query{Sequence: o.Sequence, Location: o.Location}
+			location, codonStart := cdsLocation(Query{Sequence: test.contig}, orf, 11)
+			if location != test.location || codonStart != test.codonStart {
+				t.Errorf("Frame %d ORF of %s is at %s (codon_start %d), expected %s (codon_start %d)",
+					test.frame, test.contig, location, codonStart, test.location, test.codonStart)
+			}
+		}
+		if !found {
+			t.Errorf("No frame %d ORF in %s", test.frame, test.contig)
+		}
+	}
+
+}
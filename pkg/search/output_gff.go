@@ -0,0 +1,128 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+const (
+	GFF_HEADER = "##gff-version 3\n"
+	GFF_SOURCE = "kaamer"
+)
+
+// gffEscaper escapes the characters reserved in GFF3 columns and attributes
+var gffEscaper = strings.NewReplacer(
+	"%", "%25",
+	"\t", "%09",
+	"\n", "%0A",
+	"\r", "%0D",
+	";", "%3B",
+	"=", "%3D",
+	"&", "%26",
+	",", "%2C",
+)
+
+// gffTag returns the lowercase attribute tag of a database feature
+// (ProteinName -> protein_name), GFF3 reserves the capitalized tags
+func gffTag(feature string) string {
+	tag := ""
+	for i, c := range feature {
+		if i > 0 && c >= 'A' && c <= 'Z' && feature[i-1] >= 'a' && feature[i-1] <= 'z' {
+			tag += "_"
+		}
+		tag += string(c)
+	}
+	return gffEscaper.Replace(strings.ToLower(tag))
+}
+
+// FormatGFF returns the GFF3 CDS feature of an ORF query result
+// annotated with its best hit (the first one)
+func FormatGFF(qR QueryResult, searchOptions SearchOptions, dbStats kvstore.KStats) string {
+
+	if len(qR.SearchResults.Hits) == 0 {
+		return ""
+	}
+
+	h := qR.SearchResults.Hits[0]
+	hit := qR.HitEntries[h.Key]
+
+	seqId := gffEscaper.Replace(strings.Split(qR.Query.Name, " ")[0])
+	start, end := qR.Query.Location.Bounds()
+	strand := "+"
+	if !qR.Query.Location.PlusStrand {
+		strand = "-"
+	}
+	score := "."
+	if searchOptions.Align && h.Alignment != nil {
+		score = fmt.Sprintf("%.2f", h.Alignment.BitScore)
+	}
+
+	output := ""
+	output += seqId
+	output += "\t"
+	output += GFF_SOURCE
+	output += "\t"
+	output += "CDS"
+	output += "\t"
+	output += strconv.Itoa(start)
+	output += "\t"
+	output += strconv.Itoa(end)
+	output += "\t"
+	output += score
+	output += "\t"
+	output += strand
+	output += "\t"
+	output += "0"
+	output += "\t"
+
+	// attributes
+	output += "ID=" + gffEscaper.Replace(ORFId(qR.Query))
+	output += ";Name=" + gffEscaper.Replace(hit.EntryId)
+	output += ";kmatch_identity=" + fmt.Sprintf("%.2f", (float32(h.Kmatch)/float32(qR.Query.SizeInKmer)*float32(100.00)))
+	output += ";kmatch=" + strconv.Itoa(int(h.Kmatch))
+	if searchOptions.Align && h.Alignment != nil {
+		output += ";identity=" + fmt.Sprintf("%.2f", h.Alignment.Identity)
+		output += ";evalue=" + fmt.Sprintf("%e", h.Alignment.EValue)
+	}
+	for _, annotation := range dbStats.Features {
+		value := hit.Features[annotation]
+		if value == "" {
+			continue
+		}
+		if annotation == "GO" {
+			// GO terms go in the reserved multi-valued Ontology_term attribute
+			terms := []string{}
+			for _, term := range strings.Split(value, ";") {
+				if term = strings.TrimSpace(term); term != "" {
+					terms = append(terms, gffEscaper.Replace(term))
+				}
+			}
+			output += ";Ontology_term=" + strings.Join(terms, ",")
+		} else {
+			output += ";" + gffTag(annotation) + "=" + gffEscaper.Replace(value)
+		}
+	}
+	output += "\n"
+
+	return output
+
+}
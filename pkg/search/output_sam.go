@@ -0,0 +1,169 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger"
+	"github.com/golang/protobuf/proto"
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+const (
+	SAM_FLAG_REVERSE   = 16
+	SAM_FLAG_SECONDARY = 256
+)
+
+// WriteSAMHeader writes the SAM header to w with every protein of the
+// database as a reference sequence
+func WriteSAMHeader(w io.Writer, kvStores *kvstore.KVStores) error {
+
+	io.WriteString(w, "@HD\tVN:1.5\tSO:unsorted\n")
+
+	err := kvStores.ProteinStore.DB.View(func(txn *badger.Txn) error {
+		iteratorOptions := badger.DefaultIteratorOptions
+		iteratorOptions.PrefetchSize = 100
+		it := txn.NewIterator(iteratorOptions)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			// protein entries have a 4 bytes id key (skip db_stats)
+			if len(item.Key()) != 4 {
+				continue
+			}
+			prot := &kvstore.Protein{}
+			err := item.Value(func(val []byte) error {
+				return proto.Unmarshal(val, prot)
+			})
+			if err != nil {
+				return err
+			}
+			io.WriteString(w, fmt.Sprintf("@SQ\tSN:%s\tLN:%d\n", prot.EntryId, prot.Length))
+		}
+		return nil
+	})
+
+	io.WriteString(w, "@PG\tID:kaamer\tPN:kaamer\n")
+	io.WriteString(w, "@CO\tTranslated alignments of query ORFs, SEQ is the aligned ORF segment (amino acids)\n")
+	io.WriteString(w, "@CO\tAS: bit score, NM: edit distance, ZK: kmer matches, ZF: frame, ZE: e-value, ZI: percent identity, ZL: reference length, ZR: raw score, ZS: query start (nucleotide)\n")
+
+	return err
+
+}
+
+// FormatSAM returns the SAM records of the hits of an ORF query result,
+// the first hit is the primary alignment
+func FormatSAM(qR QueryResult) string {
+
+	output := ""
+	queryName := strings.Split(qR.Query.Name, " ")[0]
+	querySeq := strings.TrimSuffix(qR.Query.Sequence, "*")
+
+	for i, h := range qR.SearchResults.Hits {
+
+		hit := qR.HitEntries[h.Key]
+		aln := h.Alignment
+
+		flag := 0
+		if !qR.Query.Location.PlusStrand {
+			flag |= SAM_FLAG_REVERSE
+		}
+		if i > 0 {
+			flag |= SAM_FLAG_SECONDARY
+		}
+
+		cigar, editDistance := samCigar(aln.AlnString)
+		seq := "*"
+		if aln.QueryStart > 0 && aln.QueryEnd <= len(querySeq) && aln.QueryStart <= aln.QueryEnd {
+			seq = querySeq[aln.QueryStart-1 : aln.QueryEnd]
+		}
+		qStart, _ := proteinToNucleotidePositions(qR.Query.Location, aln.QueryStart, aln.QueryEnd)
+
+		output += queryName
+		output += "\t"
+		output += strconv.Itoa(flag)
+		output += "\t"
+		output += hit.EntryId
+		output += "\t"
+		output += strconv.Itoa(aln.SubjectStart)
+		output += "\t"
+		output += "255"
+		output += "\t"
+		output += cigar
+		output += "\t"
+		output += "*\t0\t0"
+		output += "\t"
+		output += seq
+		output += "\t"
+		output += "*"
+		output += fmt.Sprintf("\tAS:i:%d", int(aln.BitScore))
+		output += fmt.Sprintf("\tNM:i:%d", editDistance)
+		output += fmt.Sprintf("\tZK:i:%d", h.Kmatch)
+		output += fmt.Sprintf("\tZF:i:%d", qR.Query.Location.Frame)
+		output += fmt.Sprintf("\tZE:f:%.2e", aln.EValue)
+		output += fmt.Sprintf("\tZI:i:%d", int(aln.Identity))
+		output += fmt.Sprintf("\tZL:i:%d", hit.Length)
+		output += fmt.Sprintf("\tZR:i:%d", aln.Raw)
+		output += fmt.Sprintf("\tZS:i:%d", qStart)
+		output += "\n"
+
+	}
+
+	return output
+
+}
+
+// samCigar returns the CIGAR string and edit distance of an alignment
+// string (query, match line and subject, see align.AlignmentResult)
+func samCigar(alnString string) (string, int) {
+
+	alnLines := strings.Split(alnString, "\n")
+	if len(alnLines) != 3 || len(alnLines[0]) != len(alnLines[2]) || len(alnLines[0]) == 0 {
+		return "*", 0
+	}
+
+	cigar := ""
+	editDistance := 0
+	lastOp := byte(0)
+	opLength := 0
+
+	for i := 0; i < len(alnLines[0]); i++ {
+		op := byte('M')
+		if alnLines[0][i] == '-' {
+			op = 'D'
+		} else if alnLines[2][i] == '-' {
+			op = 'I'
+		}
+		if op != 'M' || alnLines[0][i] != alnLines[2][i] {
+			editDistance++
+		}
+		if op != lastOp && opLength > 0 {
+			cigar += strconv.Itoa(opLength) + string(lastOp)
+			opLength = 0
+		}
+		lastOp = op
+		opLength++
+	}
+	cigar += strconv.Itoa(opLength) + string(lastOp)
+
+	return cigar, editDistance
+
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+const (
+	XML_EXPECT = 10 // e-value reported for hits without alignment
+)
+
+// FormatXMLHeader returns the BLAST XML document opening up to the
+// iterations element
+func FormatXMLHeader(dbStats kvstore.KStats) string {
+
+	output := ""
+	output += "<?xml version=\"1.0\"?>\n"
+	output += "<!DOCTYPE BlastOutput PUBLIC \"-//NCBI//NCBI BlastOutput/EN\" \"http://www.ncbi.nlm.nih.gov/dtd/NCBI_BlastOutput.dtd\">\n"
+	output += "<BlastOutput>\n"
+	output += "  <BlastOutput_program>blastp</BlastOutput_program>\n"
+	output += "  <BlastOutput_version>kAAmer</BlastOutput_version>\n"
+	output += "  <BlastOutput_reference>kAAmer : a fast protein database search engine</BlastOutput_reference>\n"
+	output += "  <BlastOutput_db>kAAmer</BlastOutput_db>\n"
+	output += "  <BlastOutput_query-ID>Query_1</BlastOutput_query-ID>\n"
+	output += "  <BlastOutput_query-def></BlastOutput_query-def>\n"
+	output += "  <BlastOutput_query-len>0</BlastOutput_query-len>\n"
+	output += "  <BlastOutput_param>\n"
+	output += "    <Parameters>\n"
+	output += "      <Parameters_matrix>BLOSUM62</Parameters_matrix>\n"
+	output += fmt.Sprintf("      <Parameters_expect>%d</Parameters_expect>\n", XML_EXPECT)
+	output += "      <Parameters_gap-open>11</Parameters_gap-open>\n"
+	output += "      <Parameters_gap-extend>1</Parameters_gap-extend>\n"
+	output += "      <Parameters_filter>F</Parameters_filter>\n"
+	output += "    </Parameters>\n"
+	output += "  </BlastOutput_param>\n"
+	output += "  <BlastOutput_iterations>\n"
+
+	return output
+
+}
+
+// FormatXMLIterationStart returns the opening of the iteration number num
+// (it is numbered by the single results writer)
+func FormatXMLIterationStart(num int) string {
+	output := ""
+	output += "    <Iteration>\n"
+	output += fmt.Sprintf("      <Iteration_iter-num>%d</Iteration_iter-num>\n", num)
+	output += fmt.Sprintf("      <Iteration_query-ID>Query_%d</Iteration_query-ID>\n", num)
+	return output
+}
+
+// xmlSegment returns the residues start..end (1-based) of sequence, or an
+// empty segment when the sequence doesn't cover them
+func xmlSegment(sequence string, start int, end int) string {
+	if start < 1 || end < start || end > len(sequence) {
+		return ""
+	}
+	return sequence[start-1 : end]
+}
+
+// FormatXMLIteration returns the end of the BLAST XML iteration of a query
+// (see FormatXMLIterationStart) with its hits.
+// ORFs of nucleotide queries are reported as protein queries with their
+// location appended to the query definition ([location=start..end]).
+// Without alignment, the HSP has kmer values : Hsp_score, Hsp_identity and
+// Hsp_positive are the kmer matches (KMatch), positions are the span of the
+// shared kmers (KmerSpan), Hsp_align-len is the query length in kmers,
+// Hsp_bit-score is 0, Hsp_evalue is XML_EXPECT and Hsp_qseq / Hsp_hseq are the
+// unaligned KmerSpan segments (Hsp_qseq and Hsp_hseq are always written, they
+// are required by the BLAST XML DTD).
+func FormatXMLIteration(qR QueryResult, searchOptions SearchOptions, dbStats kvstore.KStats) string {
+
+	querySeq := strings.TrimSuffix(qR.Query.Sequence, "*")
+	queryDef := qR.Query.Name
+	if searchOptions.SequenceType != PROTEIN {
+		start, end := qR.Query.Location.Bounds()
+		location := fmt.Sprintf("%d..%d", start, end)
+		if !qR.Query.Location.PlusStrand {
+			location = "complement(" + location + ")"
+		}
+		queryDef += " [location=" + location + "]"
+	}
+
+	output := ""
+	output += "      <Iteration_query-def>" + xmlEscape(queryDef) + "</Iteration_query-def>\n"
+	output += fmt.Sprintf("      <Iteration_query-len>%d</Iteration_query-len>\n", len(querySeq))
+	output += "      <Iteration_hits>\n"
+
+	for i, h := range qR.SearchResults.Hits {
+
+		hit := qR.HitEntries[h.Key]
+
+		output += "        <Hit>\n"
+		output += fmt.Sprintf("          <Hit_num>%d</Hit_num>\n", i+1)
+		output += "          <Hit_id>" + xmlEscape(hit.EntryId) + "</Hit_id>\n"
+		output += "          <Hit_def>" + xmlEscape(strings.Split(hit.Features["ProteinName"], ";;")[0]) + "</Hit_def>\n"
+		output += "          <Hit_accession>" + xmlEscape(hit.EntryId) + "</Hit_accession>\n"
+		output += fmt.Sprintf("          <Hit_len>%d</Hit_len>\n", hit.Length)
+		output += "          <Hit_hsps>\n"
+		output += "            <Hsp>\n"
+		output += "              <Hsp_num>1</Hsp_num>\n"
+
+		if searchOptions.Align {
+			aln := h.Alignment
+			output += fmt.Sprintf("              <Hsp_bit-score>%g</Hsp_bit-score>\n", aln.BitScore)
+			output += fmt.Sprintf("              <Hsp_score>%d</Hsp_score>\n", aln.Raw)
+			output += fmt.Sprintf("              <Hsp_evalue>%g</Hsp_evalue>\n", aln.EValue)
+			output += fmt.Sprintf("              <Hsp_query-from>%d</Hsp_query-from>\n", aln.QueryStart)
+			output += fmt.Sprintf("              <Hsp_query-to>%d</Hsp_query-to>\n", aln.QueryEnd)
+			output += fmt.Sprintf("              <Hsp_hit-from>%d</Hsp_hit-from>\n", aln.SubjectStart)
+			output += fmt.Sprintf("              <Hsp_hit-to>%d</Hsp_hit-to>\n", aln.SubjectEnd)
+			output += "              <Hsp_query-frame>0</Hsp_query-frame>\n"
+			output += "              <Hsp_hit-frame>0</Hsp_hit-frame>\n"
+			output += fmt.Sprintf("              <Hsp_identity>%d</Hsp_identity>\n", int(float32(aln.Length)*aln.Identity/100+0.5))
+			output += fmt.Sprintf("              <Hsp_positive>%d</Hsp_positive>\n", int(float32(aln.Length)*aln.Similarity/100+0.5))
+			alnLines := strings.Split(aln.AlnString, "\n")
+			if len(alnLines) == 3 {
+				output += fmt.Sprintf("              <Hsp_gaps>%d</Hsp_gaps>\n", strings.Count(alnLines[0], "-")+strings.Count(alnLines[2], "-"))
+				output += fmt.Sprintf("              <Hsp_align-len>%d</Hsp_align-len>\n", aln.Length)
+				output += "              <Hsp_qseq>" + xmlEscape(alnLines[0]) + "</Hsp_qseq>\n"
+				output += "              <Hsp_hseq>" + xmlEscape(alnLines[2]) + "</Hsp_hseq>\n"
+				output += "              <Hsp_midline>" + xmlEscape(alnLines[1]) + "</Hsp_midline>\n"
+			} else {
+				output += "              <Hsp_gaps>0</Hsp_gaps>\n"
+				output += fmt.Sprintf("              <Hsp_align-len>%d</Hsp_align-len>\n", aln.Length)
+				output += "              <Hsp_qseq></Hsp_qseq>\n"
+				output += "              <Hsp_hseq></Hsp_hseq>\n"
+			}
+		} else {
+			qStart, qEnd, sStart, sEnd := qR.Span(h)
+			output += "              <Hsp_bit-score>0</Hsp_bit-score>\n"
+			output += fmt.Sprintf("              <Hsp_score>%d</Hsp_score>\n", h.Kmatch)
+			output += fmt.Sprintf("              <Hsp_evalue>%d</Hsp_evalue>\n", XML_EXPECT)
+			output += fmt.Sprintf("              <Hsp_query-from>%d</Hsp_query-from>\n", qStart)
+			output += fmt.Sprintf("              <Hsp_query-to>%d</Hsp_query-to>\n", qEnd)
+			output += fmt.Sprintf("              <Hsp_hit-from>%d</Hsp_hit-from>\n", sStart)
+			output += fmt.Sprintf("              <Hsp_hit-to>%d</Hsp_hit-to>\n", sEnd)
+			output += "              <Hsp_query-frame>0</Hsp_query-frame>\n"
+			output += "              <Hsp_hit-frame>0</Hsp_hit-frame>\n"
+			output += fmt.Sprintf("              <Hsp_identity>%d</Hsp_identity>\n", h.Kmatch)
+			output += fmt.Sprintf("              <Hsp_positive>%d</Hsp_positive>\n", h.Kmatch)
+			output += "              <Hsp_gaps>0</Hsp_gaps>\n"
+			output += fmt.Sprintf("              <Hsp_align-len>%d</Hsp_align-len>\n", qR.Query.SizeInKmer)
+			output += "              <Hsp_qseq>" + xmlEscape(xmlSegment(querySeq, qStart, qEnd)) + "</Hsp_qseq>\n"
+			output += "              <Hsp_hseq>" + xmlEscape(xmlSegment(hit.Sequence, sStart, sEnd)) + "</Hsp_hseq>\n"
+		}
+
+		output += "            </Hsp>\n"
+		output += "          </Hit_hsps>\n"
+		output += "        </Hit>\n"
+
+	}
+
+	output += "      </Iteration_hits>\n"
+	output += "      <Iteration_stat>\n"
+	output += "        <Statistics>\n"
+	output += fmt.Sprintf("          <Statistics_db-num>%d</Statistics_db-num>\n", dbStats.NumberOfProteins)
+	output += fmt.Sprintf("          <Statistics_db-len>%d</Statistics_db-len>\n", dbStats.NumberOfAA)
+	output += "          <Statistics_hsp-len>0</Statistics_hsp-len>\n"
+	output += "          <Statistics_eff-space>0</Statistics_eff-space>\n"
+	output += "          <Statistics_kappa>0.041</Statistics_kappa>\n"
+	output += "          <Statistics_lambda>0.267</Statistics_lambda>\n"
+	output += "          <Statistics_entropy>0.14</Statistics_entropy>\n"
+	output += "        </Statistics>\n"
+	output += "      </Iteration_stat>\n"
+	output += "    </Iteration>\n"
+
+	return output
+
+}
+
+// FormatXMLFooter returns the BLAST XML document closing
+func FormatXMLFooter(timedOut bool) string {
+
+	output := ""
+	output += "  </BlastOutput_iterations>\n"
+	if timedOut {
+		output += "  <!-- search timed out, results are partial -->\n"
+	}
+	output += "</BlastOutput>\n"
+
+	return output
+
+}
+
+func xmlEscape(s string) string {
+	buf := new(bytes.Buffer)
+	xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
@@ -19,9 +19,12 @@ package search
 import (
 	"bufio"
 	"compress/gzip"
+	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -39,19 +42,27 @@ import (
 )
 
 const (
-	NUCLEOTIDE    = 0
-	PROTEIN       = 1
-	READS         = 2
-	KMER_SIZE     = 7
-	DNA_QUERY     = "DNA Query"
-	PROTEIN_QUERY = "Protein Query"
+	NUCLEOTIDE           = 0
+	PROTEIN              = 1
+	READS                = 2
+	DNA_QUERY            = "DNA Query"
+	PROTEIN_QUERY        = "Protein Query"
+	DEFAULT_KMATCH_RATIO = 0.05       // at least 5% of kmer hits (on query)
+	DEFAULT_MIN_KMATCH   = 10         // at least 10 kmer hits
+	SCORING_KMER         = "kmer"     // number of shared kmers
+	SCORING_DIAGONAL     = "diagonal" // shared kmers of the best collinear chain
+	SCORING_IDF          = "idf"      // shared kmers weighted by their rarity
+
+	// TIMEOUT_TRAILER is the HTTP trailer telling if the search timed out
+	// (true) and results are partial, in every output format
+	TIMEOUT_TRAILER = "X-Kaamer-Timed-Out"
+	// ERROR_TRAILER is the HTTP trailer with the error that stopped a search
+	// once results were written (the response is a 400 otherwise)
+	ERROR_TRAILER = "X-Kaamer-Error"
 )
 
 var (
-	searchOptions = SearchOptions{}
-	dbStats       kvstore.KStats
-	kMatchRatio   = 0.05      // at least 5% of kmer hits (on query)
-	minKMatch     = int64(10) // at least 10 kmer hits
+	ScoringModes = map[string]bool{SCORING_KMER: true, SCORING_DIAGONAL: true, SCORING_IDF: true}
 )
 
 type SearchOptions struct {
@@ -59,13 +70,59 @@ type SearchOptions struct {
 	InputType        string
 	SequenceType     int
 	GeneticCode      int
+	ORFMode          string
+	MinORFLength     int
+	ResolveORFs      bool
+	MaxORFOverlap    int
 	OutFormat        string
+	OutColumns       []string
+	Metadata         bool // leading metadata line in ndjson
+	HitSequences     bool // hit proteins after the ORFs in fasta
+	LCA              bool
+	LCAWindow        float64 // % of the best hit score
+	Summary          string  // abundance summary of read searches (SUMMARY_APPEND, SUMMARY_ONLY)
+	TopPercent       float64 // hits within this % of the best hit score (0 for all)
+	BestHits         bool    // only the best hits (ties)
+	MaxEValue        float64 // alignment cut-offs (0 for none)
+	MinIdentity      float64
+	MinQueryCover    float64
+	MinSubjectCover  float64
 	MaxResults       int
+	Scoring          string // kmer matches scoring (SCORING_KMER, SCORING_DIAGONAL, SCORING_IDF)
+	KMatchRatio      float64
+	MinKMatch        int64
+	Timeout          time.Duration
 	Align            bool
 	ExtractPositions bool
 	Annotations      bool
 }
 
+// DefaultSearchOptions returns the options of a protein search with the
+// default hit thresholds, and the default genetic code and ORF calling for
+// nucleotide or reads searches (SequenceType)
+func DefaultSearchOptions() SearchOptions {
+	return SearchOptions{
+		SequenceType:  PROTEIN,
+		GeneticCode:   11,
+		ORFMode:       ORF_START_STOP,
+		MinORFLength:  DEFAULT_MIN_ORF_LENGTH,
+		MaxORFOverlap: DEFAULT_MAX_ORF_OVERLAP,
+		OutFormat:     "tsv",
+		MaxResults:    10,
+		Scoring:       SCORING_KMER,
+		KMatchRatio:   DEFAULT_KMATCH_RATIO,
+		MinKMatch:     DEFAULT_MIN_KMATCH,
+		LCAWindow:     DEFAULT_LCA_WINDOW,
+	}
+}
+
+// ResultsMetadata is the leading line of the ndjson output format
+type ResultsMetadata struct {
+	DBProteinFeatures []string `json:"dbProteinFeatures"`
+	KmerRatio         float64  `json:"kmerRatio"`
+	MinKmerHits       int64    `json:"minKmerHits"`
+}
+
 type SearchResults struct {
 	Counter      *cnt.CounterBox
 	Hits         HitList
@@ -93,6 +150,7 @@ type QueryResult struct {
 	Query         Query
 	SearchResults *SearchResults
 	HitEntries    map[uint32]kvstore.Protein
+	LCA           *LCA `json:",omitempty"`
 }
 
 type Query struct {
@@ -108,6 +166,7 @@ type Hit struct {
 	Key       uint32
 	Kmatch    int64
 	Alignment *align.AlignmentResult
+	KmerSpan  *KmerSpan `json:",omitempty"`
 }
 
 type HitList []Hit
@@ -116,6 +175,14 @@ func (p HitList) Len() int           { return len(p) }
 func (p HitList) Less(i, j int) bool { return p[i].Kmatch < p[j].Kmatch }
 func (p HitList) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
+// Score returns the bit score of the hit if aligned, its kmer matches otherwise
+func (h Hit) Score(aligned bool) float64 {
+	if aligned && h.Alignment != nil {
+		return h.Alignment.BitScore
+	}
+	return float64(h.Kmatch)
+}
+
 func syncMapLen(syncMap *sync.Map) int {
 	length := 0
 	syncMap.Range(func(_, _ interface{}) bool {
@@ -137,7 +204,7 @@ func sortMapByValue(hitFrequencies *sync.Map) HitList {
 			if err != nil {
 				log.Fatal(err.Error())
 			}
-			pl[i] = Hit{uint32(idUint32), item.Value(), &align.AlignmentResult{}}
+			pl[i] = Hit{Key: uint32(idUint32), Kmatch: item.Value(), Alignment: &align.AlignmentResult{}}
 			i++
 		}
 		return true
@@ -147,60 +214,106 @@ func sortMapByValue(hitFrequencies *sync.Map) HitList {
 	return pl
 }
 
-func NewSearchResult(newSearchOptions SearchOptions, _dbStats kvstore.KStats, kvStores *kvstore.KVStores, nbOfThreads int, w http.ResponseWriter, r *http.Request) []QueryResult {
+// NewSearchResult runs a search and writes its results to an http response
+// in the requested output format (searchOptions.OutFormat).
+// The search is cancelled when the client goes away.
+func NewSearchResult(searchOptions SearchOptions, searcher *Searcher, w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
 
-	// Query cancellation
-	cancelQuery := false
-	go func(cancelQuery *bool) {
-	again:
-		select {
-		case <-time.After(3 * time.Second):
-			goto again
-		case <-r.Context().Done():
-			*cancelQuery = true
+	input, err := OpenQueries(searchOptions.File)
+	if err != nil {
+		if searchOptions.InputType != "path" {
+			os.Remove(searchOptions.File)
+		}
+		w.WriteHeader(400)
+		fmt.Fprintln(w, err.Error())
+		return
+	}
+	defer input.Close()
+
+	// Single query results writer
+	queryWriterChan := make(chan []byte, 10)
+	wgResWriter := new(sync.WaitGroup)
+	wgResWriter.Add(1)
+	started := false
+	go QueryResultWriter(ctx, queryWriterChan, w, searchOptions, searcher.KVStores, searcher.DBStats, &started, wgResWriter)
+
+	// Concurrent query result handlers
+	// or contig records written once all the ORFs are known
+	queryResultChan := make(chan QueryResult, 10)
+	wgResHandler := new(sync.WaitGroup)
+	var records *ContigRecords
+	if RecordFormats[searchOptions.OutFormat] {
+		records = NewContigRecords()
+		wgResHandler.Add(1)
+		go records.Collect(ctx, queryResultChan, wgResHandler)
+	} else {
+		for i := 0; i < searcher.NbOfThreads; i++ {
+			wgResHandler.Add(1)
+			go QueryResultHandler(ctx, queryResultChan, queryWriterChan, searchOptions, searcher.DBStats, wgResHandler)
 		}
-	}(&cancelQuery)
+	}
+
+	// Abundance summary of the read results
+	var abundance *Abundance
+	searchResultChan := queryResultChan
+	if searchOptions.Summary != "" {
+		abundance = NewAbundance()
+		searchResultChan = make(chan QueryResult, 10)
+		wgResHandler.Add(1)
+		go abundance.Collect(searchResultChan, queryResultChan, searchOptions.Summary == SUMMARY_APPEND, wgResHandler)
+	}
 
-	queryResults := []QueryResult{}
+	err = searcher.SearchReader(ctx, searchOptions, input, searchResultChan)
 
-	searchOptions = newSearchOptions
-	dbStats = _dbStats
+	wgResHandler.Wait()
+	if records != nil && ctx.Err() == nil {
+		records.WriteRecords(ctx, queryWriterChan, searchOptions, err == context.DeadlineExceeded)
+	}
+	close(queryWriterChan)
+	wgResWriter.Wait()
 
-	switch searchOptions.SequenceType {
-	case READS:
-		NucleotideSearch(searchOptions, kvStores, nbOfThreads, w, true, &cancelQuery)
-	case NUCLEOTIDE:
-		NucleotideSearch(searchOptions, kvStores, nbOfThreads, w, false, &cancelQuery)
-	case PROTEIN:
-		ProteinSearch(searchOptions, kvStores, nbOfThreads, w, &cancelQuery)
+	timedOut := err == context.DeadlineExceeded
+	if ctx.Err() == nil {
+		if err != nil && !timedOut {
+			log.Printf("Search error : %s\n", err.Error())
+		}
+		switch {
+		case err != nil && !timedOut && !started:
+			w.WriteHeader(400)
+			fmt.Fprintln(w, err.Error())
+		case err != nil && !timedOut:
+			SetResponseFooter(w, searchOptions, false, abundance)
+			w.Header().Set(ERROR_TRAILER, strings.Replace(err.Error(), "\n", " ", -1))
+		default:
+			if !started {
+				SetResponseFormatAndHeader(w, searchOptions, searcher.KVStores, searcher.DBStats)
+			}
+			SetResponseFooter(w, searchOptions, timedOut, abundance)
+		}
 	}
 
 	if searchOptions.InputType != "path" {
 		os.Remove(searchOptions.File)
 	}
 
-	return queryResults
-
 }
 
-func (queryResult *QueryResult) FilterResults() {
+// FilterResults removes the hits failing the -kratio and -kmin filters.
+// Without alignment, the -top / -best filters are applied before the
+// MaxResults cut. With alignment they are applied on the aligned hits (see
+// AlignAndFilterHits) so every hit is kept for the alignment.
+func (queryResult *QueryResult) FilterResults(searchOptions SearchOptions) {
 
-	var hitsToDelete []uint32
 	var lastGoodHitPosition = len(queryResult.SearchResults.Hits) - 1
 
 	for i, hit := range queryResult.SearchResults.Hits {
-		if (float64(hit.Kmatch)/float64(queryResult.Query.SizeInKmer)) < kMatchRatio || hit.Kmatch < minKMatch {
+		if (float64(hit.Kmatch)/float64(queryResult.Query.SizeInKmer)) < searchOptions.KMatchRatio || hit.Kmatch < searchOptions.MinKMatch {
 			if lastGoodHitPosition == (len(queryResult.SearchResults.Hits) - 1) {
 				lastGoodHitPosition = i - 1
 			}
-			hitsToDelete = append(hitsToDelete, hit.Key)
-		}
-	}
-
-	if lastGoodHitPosition >= searchOptions.MaxResults {
-		lastGoodHitPosition = searchOptions.MaxResults - 1
-		for _, h := range queryResult.SearchResults.Hits[lastGoodHitPosition+1:] {
-			hitsToDelete = append(hitsToDelete, h.Key)
+			queryResult.removeHit(hit.Key)
 		}
 	}
 
@@ -210,72 +323,164 @@ func (queryResult *QueryResult) FilterResults() {
 		queryResult.SearchResults.Hits = queryResult.SearchResults.Hits[0 : lastGoodHitPosition+1]
 	}
 
-	for _, k := range hitsToDelete {
-		delete(queryResult.SearchResults.PositionHits, k)
+	if !searchOptions.Align {
+		queryResult.FilterTopHits(searchOptions, false)
+		queryResult.FilterMaxResults(searchOptions)
+	} else if !searchOptions.BestHits && searchOptions.TopPercent <= 0 {
+		queryResult.FilterMaxResults(searchOptions)
 	}
 
 }
 
-func GetQueriesFasta(fileName string, queryChan chan<- Query, isProtein bool, cancelQuery *bool) {
+// FilterMaxResults keeps the searchOptions.MaxResults first hits, all the hits
+// are kept with searchOptions.BestHits (ties)
+func (queryResult *QueryResult) FilterMaxResults(searchOptions SearchOptions) {
 
-	loc := Location{
-		StartPosition:     1,
-		EndPosition:       0,
-		PlusStrand:        true,
-		StartsAlternative: []int{},
+	if searchOptions.BestHits || len(queryResult.SearchResults.Hits) <= searchOptions.MaxResults {
+		return
 	}
-	query := Query{
-		Sequence:   "",
-		Name:       "",
-		SizeInKmer: 0,
-		Location:   loc,
-		Type:       "",
-		Contig:     "",
+
+	for _, h := range queryResult.SearchResults.Hits[searchOptions.MaxResults:] {
+		queryResult.removeHit(h.Key)
 	}
+	queryResult.SearchResults.Hits = queryResult.SearchResults.Hits[:searchOptions.MaxResults]
+
+}
+
+// FilterTopHits keeps the hits scoring within searchOptions.TopPercent % of
+// the best hit, or only the hits with the best score if searchOptions.BestHits.
+// Scores are bit scores if aligned, kmer matches otherwise.
+func (queryResult *QueryResult) FilterTopHits(searchOptions SearchOptions, aligned bool) {
+
+	if !searchOptions.BestHits && searchOptions.TopPercent <= 0 {
+		return
+	}
+
+	bestScore := 0.0
+	for _, h := range queryResult.SearchResults.Hits {
+		if score := h.Score(aligned); score > bestScore {
+			bestScore = score
+		}
+	}
+
+	minScore := bestScore * (1 - searchOptions.TopPercent/100)
+	if searchOptions.BestHits {
+		minScore = bestScore
+	}
+
+	topHits := HitList{}
+	for _, h := range queryResult.SearchResults.Hits {
+		if h.Score(aligned) >= minScore {
+			topHits = append(topHits, h)
+		} else {
+			queryResult.removeHit(h.Key)
+		}
+	}
+	queryResult.SearchResults.Hits = topHits
+
+}
+
+// OpenQueries opens a plain or gzipped query file
+func OpenQueries(fileName string) (io.ReadCloser, error) {
 
-	// queries := []Query{}
 	file, err := os.Open(fileName)
-	defer file.Close()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	// check filetype
-	buff := make([]byte, 32)
-	_, err = file.Read(buff)
+	reader, err := NewQueryReader(file)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		file.Close()
+		return nil, fmt.Errorf("Query file %s : %s", fileName, err.Error())
 	}
-	file.Seek(0, 0)
 
-	filetype := http.DetectContentType(buff)
+	return &queryFile{Reader: reader, file: file}, nil
 
-	scanner := new(bufio.Scanner)
+}
 
-	if filetype == "application/x-gzip" {
-		gz, err := gzip.NewReader(file)
-		if err != nil {
-			fmt.Println(err.Error())
-			return
-		}
-		scanner = bufio.NewScanner(gz)
-		defer gz.Close()
-	} else if filetype == "text/plain; charset=utf-8" {
-		scanner = bufio.NewScanner(file)
-	} else {
-		return
+type queryFile struct {
+	io.Reader
+	file *os.File
+}
+
+func (q *queryFile) Close() error {
+	if gz, ok := q.Reader.(*gzip.Reader); ok {
+		gz.Close()
+	}
+	return q.file.Close()
+}
+
+// NewQueryReader returns a reader of the plain text queries of r, gzipped
+// queries are decompressed
+func NewQueryReader(r io.Reader) (io.Reader, error) {
+
+	// check filetype
+	reader := bufio.NewReader(r)
+	buff, _ := reader.Peek(32)
+	if len(buff) == 0 {
+		return nil, errors.New("No query sequence (empty input)")
+	}
+
+	switch http.DetectContentType(buff) {
+	case "application/x-gzip":
+		return gzip.NewReader(reader)
+	case "text/plain; charset=utf-8":
+		return reader, nil
+	default:
+		return nil, errors.New("Queries need to be plain text or gzipped fasta / fastq")
+	}
+
+}
+
+// NewQuery returns the query of a sequence of sequenceType (PROTEIN,
+// NUCLEOTIDE contig or READS), its size in kmers counts the windows of
+// kmerSpan residues (see kvstore.KScheme)
+func NewQuery(name string, sequence string, sequenceType int, kmerSpan int) Query {
+
+	query := Query{
+		Sequence: sequence,
+		Name:     name,
+		Location: Location{
+			StartPosition:     1,
+			EndPosition:       len(sequence),
+			PlusStrand:        true,
+			StartsAlternative: []int{},
+		},
 	}
 
+	if sequenceType == NUCLEOTIDE {
+		query.Contig = name
+	}
+
+	query.SizeInKmer = len(sequence) - kmerSpan + 1
+	if sequenceType != READS && strings.HasSuffix(sequence, "*") {
+		query.SizeInKmer--
+	}
+
+	return query
+
+}
+
+// GetQueriesFasta sends the fasta queries read from r to queryChan, nucleotide
+// queries are contigs (isProtein false)
+func GetQueriesFasta(ctx context.Context, r io.Reader, queryChan chan<- Query, isProtein bool, kmerSpan int) error {
+
+	sequenceType := NUCLEOTIDE
+	if isProtein {
+		sequenceType = PROTEIN
+	}
+
+	scanner := bufio.NewScanner(r)
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
 	l := ""
 	queryName := ""
+	sequence := ""
 
 	for scanner.Scan() {
 
-		if *cancelQuery {
-			break
+		if ctx.Err() != nil {
+			return nil
 		}
 
 		l = scanner.Text()
@@ -283,102 +488,45 @@ func GetQueriesFasta(fileName string, queryChan chan<- Query, isProtein bool, ca
 			continue
 		}
 		if l[0] == '>' {
-			if query.Sequence != "" {
-				query.SizeInKmer = len(query.Sequence) - KMER_SIZE + 1
-				if query.Sequence[len(query.Sequence)-1:] == "*" {
-					query.SizeInKmer--
+			if sequence != "" {
+				if !sendQuery(ctx, queryChan, NewQuery(queryName, sequence, sequenceType, kmerSpan)) {
+					return nil
 				}
-				query.Location.EndPosition = len(query.Sequence)
-				queryChan <- query
-				query = Query{Sequence: "", Name: "", SizeInKmer: 0, Contig: ""}
+				sequence = ""
 			}
 			queryName = strings.TrimSuffix(l[1:], "\n")
-			if isProtein {
-				query.Name = queryName
-				query.Contig = ""
-				query.Location.StartPosition = 1
-			} else {
-				query.Name = queryName
-				query.Contig = queryName
-			}
 		} else {
-			query.Sequence += strings.TrimSpace(l)
+			sequence += strings.TrimSpace(l)
 		}
 	}
 
-	if query.Sequence != "" {
-		query.SizeInKmer = len(query.Sequence) - KMER_SIZE + 1
-		if query.Sequence[len(query.Sequence)-1:] == "*" {
-			query.SizeInKmer--
-		}
-		query.Location.EndPosition = len(query.Sequence)
-		queryChan <- query
+	if err := scanner.Err(); err != nil {
+		return err
 	}
 
-}
-
-func GetQueriesFastq(fileName string, queryChan chan<- Query, cancelQuery *bool) {
-
-	loc := Location{
-		StartPosition:     1,
-		EndPosition:       0,
-		PlusStrand:        true,
-		StartsAlternative: []int{},
-	}
-	query := Query{
-		Sequence:   "",
-		Name:       "",
-		SizeInKmer: 0,
-		Location:   loc,
-		Type:       "",
-		Contig:     "",
+	if sequence != "" {
+		sendQuery(ctx, queryChan, NewQuery(queryName, sequence, sequenceType, kmerSpan))
 	}
 
-	// queries := []Query{}
-	file, err := os.Open(fileName)
-	defer file.Close()
-	if err != nil {
-		log.Fatal(err)
-	}
+	return nil
 
-	// check filetype
-	buff := make([]byte, 32)
-	_, err = file.Read(buff)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-	file.Seek(0, 0)
-
-	filetype := http.DetectContentType(buff)
+}
 
-	scanner := new(bufio.Scanner)
-
-	if filetype == "application/x-gzip" {
-		// fmt.Println("Loaded gzip file")
-		gz, err := gzip.NewReader(file)
-		if err != nil {
-			fmt.Println(err.Error())
-			return
-		}
-		scanner = bufio.NewScanner(gz)
-		// defer gz.Close()
-	} else if filetype == "text/plain; charset=utf-8" {
-		scanner = bufio.NewScanner(file)
-	} else {
-		return
-	}
+// GetQueriesFastq sends the fastq reads read from r to queryChan
+func GetQueriesFastq(ctx context.Context, r io.Reader, queryChan chan<- Query, kmerSpan int) error {
 
+	scanner := bufio.NewScanner(r)
 	isSequence := regexp.MustCompile(`^[ATGCNatgcn]+$`).MatchString
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
-	seqNb := 0
 	l := ""
+	queryName := ""
+	sequence := ""
 
 	for scanner.Scan() {
 
-		if *cancelQuery {
-			break
+		if ctx.Err() != nil {
+			return nil
 		}
 
 		l = scanner.Text()
@@ -386,34 +534,52 @@ func GetQueriesFastq(fileName string, queryChan chan<- Query, cancelQuery *bool)
 			continue
 		}
 		if l[0] == '@' {
-			seqNb += 1
-			if query.Sequence != "" {
-				query.SizeInKmer = len(query.Sequence) - KMER_SIZE + 1
-				query.Location.EndPosition = len(query.Sequence)
-				queryChan <- query
-				query = Query{Sequence: "", Name: "", SizeInKmer: 0}
+			if sequence != "" {
+				if !sendQuery(ctx, queryChan, NewQuery(queryName, sequence, READS, kmerSpan)) {
+					return nil
+				}
+				sequence = ""
 			}
-			query.Name = strings.TrimSuffix(l[1:], "\n")
+			queryName = strings.TrimSuffix(l[1:], "\n")
 		} else if isSequence(l) {
-			query.Sequence = strings.TrimSuffix(l, "\n")
+			sequence = strings.TrimSuffix(l, "\n")
 		}
 	}
 
-	if query.Sequence != "" {
-		query.SizeInKmer = len(query.Sequence) - KMER_SIZE + 1
-		query.Location.EndPosition = len(query.Sequence)
-		queryChan <- query
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if sequence != "" {
+		sendQuery(ctx, queryChan, NewQuery(queryName, sequence, READS, kmerSpan))
 	}
 
+	return nil
+
 }
 
-func (searchRes *SearchResults) KmerSearch(keyChan <-chan KeyPos, kvStores *kvstore.KVStores, wg *sync.WaitGroup, matchPositionChan chan<- MatchPosition) {
+// sendQuery sends query to queryChan unless ctx is done first
+func sendQuery(ctx context.Context, queryChan chan<- Query, query Query) bool {
+	select {
+	case queryChan <- query:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (searchRes *SearchResults) KmerSearch(ctx context.Context, keyChan <-chan KeyPos, kvStores *kvstore.KVStores, dbStats kvstore.KStats, searchOptions SearchOptions, wg *sync.WaitGroup, matchPositionChan chan<- MatchPosition) {
 
 	extractPos := (searchOptions.ExtractPositions || (searchOptions.SequenceType == NUCLEOTIDE) || (searchOptions.SequenceType == READS))
 
 	defer wg.Done()
 	for keyPos := range keyChan {
 
+		// keep draining keyChan once cancelled
+		if ctx.Err() != nil {
+			continue
+		}
+
 		if kCombId, err := kvStores.KmerStore.GetValueFromBadger(keyPos.Key); err == nil {
 
 			if len(kCombId) < 1 {
@@ -424,8 +590,13 @@ func (searchRes *SearchResults) KmerSearch(keyChan <-chan KeyPos, kvStores *kvst
 			kC := &kvstore.KComb{}
 			proto.Unmarshal(kCombVal, kC)
 
+			weight := 1
+			if searchOptions.Scoring == SCORING_IDF {
+				weight = KmerWeight(kC, dbStats.NumberOfProteins)
+			}
+
 			for _, id := range kC.ProteinKeys {
-				searchRes.Counter.GetCounter(strconv.Itoa(int(id))).Increment()
+				searchRes.Counter.GetCounter(strconv.Itoa(int(id))).IncrementBy(weight)
 				if extractPos {
 					matchPositionChan <- MatchPosition{HitId: id, QPos: keyPos.Pos, QSize: keyPos.QSize}
 				}
@@ -447,25 +618,108 @@ func (searchRes *SearchResults) StoreMatchPositions(matchPosition <-chan MatchPo
 
 }
 
+// removeHit drops the positions and the entry of a filtered out hit so they
+// are not reported with the results
+func (queryResult *QueryResult) removeHit(key uint32) {
+	delete(queryResult.SearchResults.PositionHits, key)
+	delete(queryResult.HitEntries, key)
+}
+
 func (queryResult *QueryResult) FetchHitsInformation(kvStores *kvstore.KVStores) {
 
 	for _, h := range queryResult.SearchResults.Hits {
-		if _, ok := queryResult.HitEntries[h.Key]; !ok {
-			proteinId := make([]byte, 4)
-			binary.BigEndian.PutUint32(proteinId, h.Key)
-			val, err := kvStores.ProteinStore.GetValueFromBadger(proteinId)
-			if err != nil {
-				return
-			}
-			prot := &kvstore.Protein{}
-			proto.Unmarshal(val, prot)
-			queryResult.HitEntries[h.Key] = *prot
+		if !queryResult.fetchHitEntry(kvStores, h.Key) {
+			return
 		}
 	}
 
 }
 
-func QueryResultHandler(queryResult <-chan QueryResult, queryWriter chan<- []byte, w http.ResponseWriter, wg *sync.WaitGroup) {
+// fetchHitEntry adds the protein entry of a hit to HitEntries if missing,
+// false if it can't be read from the protein store
+func (queryResult *QueryResult) fetchHitEntry(kvStores *kvstore.KVStores, key uint32) bool {
+
+	if _, ok := queryResult.HitEntries[key]; ok {
+		return true
+	}
+
+	proteinId := make([]byte, 4)
+	binary.BigEndian.PutUint32(proteinId, key)
+	val, err := kvStores.ProteinStore.GetValueFromBadger(proteinId)
+	if err != nil {
+		return false
+	}
+	prot := &kvstore.Protein{}
+	proto.Unmarshal(val, prot)
+	queryResult.HitEntries[key] = *prot
+
+	return true
+
+}
+
+// AlignHits aligns the query with its hits, the hits that could not be
+// aligned are left without Alignment (nil)
+func (queryResult *QueryResult) AlignHits(ctx context.Context, dbStats kvstore.KStats) error {
+
+	for i, _ := range queryResult.SearchResults.Hits {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		alignment, err := align.Align(queryResult.Query.Sequence, queryResult.HitEntries[queryResult.SearchResults.Hits[i].Key].Sequence, dbStats, "blosum62", 11, 1)
+		if err != nil {
+			queryResult.SearchResults.Hits[i].Alignment = nil
+			continue
+		}
+		queryResult.SearchResults.Hits[i].Alignment = &alignment
+	}
+
+	return nil
+
+}
+
+// AlignAndFilterHits aligns the hits and removes the ones that could not be
+// aligned or failing the alignment cut-offs of searchOptions (e-value, % identity, % query and
+// subject coverage), the remaining hits are ranked by bit score and
+// filtered with FilterTopHits then FilterMaxResults
+func (queryResult *QueryResult) AlignAndFilterHits(ctx context.Context, searchOptions SearchOptions, dbStats kvstore.KStats) error {
+
+	if err := queryResult.AlignHits(ctx, dbStats); err != nil {
+		return err
+	}
+
+	queryLength := len(strings.TrimSuffix(queryResult.Query.Sequence, "*"))
+
+	alignedHits := HitList{}
+	for _, h := range queryResult.SearchResults.Hits {
+		aln := h.Alignment
+		hit := queryResult.HitEntries[h.Key]
+		if aln == nil {
+			queryResult.removeHit(h.Key)
+			continue
+		}
+		if (searchOptions.MaxEValue > 0 && aln.EValue > searchOptions.MaxEValue) ||
+			float64(aln.Identity) < searchOptions.MinIdentity ||
+			coveragePercent(aln.QueryStart, aln.QueryEnd, queryLength) < searchOptions.MinQueryCover ||
+			coveragePercent(aln.SubjectStart, aln.SubjectEnd, int(hit.Length)) < searchOptions.MinSubjectCover {
+			queryResult.removeHit(h.Key)
+			continue
+		}
+		alignedHits = append(alignedHits, h)
+	}
+
+	sort.SliceStable(alignedHits, func(i, j int) bool {
+		return alignedHits[i].Alignment.BitScore > alignedHits[j].Alignment.BitScore
+	})
+	queryResult.SearchResults.Hits = alignedHits
+
+	queryResult.FilterTopHits(searchOptions, true)
+	queryResult.FilterMaxResults(searchOptions)
+
+	return nil
+
+}
+
+func QueryResultHandler(ctx context.Context, queryResult <-chan QueryResult, queryWriter chan<- []byte, searchOptions SearchOptions, dbStats kvstore.KStats, wg *sync.WaitGroup) {
 
 	defer wg.Done()
 
@@ -475,15 +729,9 @@ func QueryResultHandler(queryResult <-chan QueryResult, queryWriter chan<- []byt
 
 	for qR := range queryResult {
 
-		// Launch Alignment
-		if searchOptions.Align {
-			for i, _ := range qR.SearchResults.Hits {
-				alignment, err := align.Align(qR.Query.Sequence, qR.HitEntries[qR.SearchResults.Hits[i].Key].Sequence, dbStats, "blosum62", 11, 1)
-				if err != nil {
-					continue
-				}
-				qR.SearchResults.Hits[i].Alignment = &alignment
-			}
+		// client is gone, only drain the results
+		if ctx.Err() != nil {
+			continue
 		}
 
 		// Write respopnse json
@@ -495,6 +743,48 @@ func QueryResultHandler(queryResult <-chan QueryResult, queryWriter chan<- []byt
 			queryWriter <- data
 		}
 
+		// Write respopnse ndjson (one result per line)
+		if searchOptions.OutFormat == "ndjson" {
+			data, err := json.Marshal(qR)
+			if err != nil {
+				fmt.Println(err.Error())
+				continue
+			}
+			queryWriter <- append(data, '\n')
+		}
+
+		// Write respopnse gff (best hit only)
+		if searchOptions.OutFormat == "gff" {
+			if output = FormatGFF(qR, searchOptions, dbStats); output != "" {
+				queryWriter <- []byte(output)
+			}
+		}
+
+		// Write respopnse fasta
+		if searchOptions.OutFormat == "fasta" {
+			queryWriter <- []byte(FormatFasta(qR, searchOptions))
+		}
+
+		// Write respopnse sam
+		if searchOptions.OutFormat == "sam" {
+			queryWriter <- []byte(FormatSAM(qR))
+		}
+
+		// Write respopnse blast xml (iterations are opened by the writer)
+		if searchOptions.OutFormat == "xml" {
+			queryWriter <- []byte(FormatXMLIteration(qR, searchOptions, dbStats))
+		}
+
+		// Write respopnse blast tabular
+		if BlastFormats[searchOptions.OutFormat] {
+			output = ""
+			if searchOptions.OutFormat == "blast7" {
+				output += FormatBlastComments(qR, searchOptions)
+			}
+			output += FormatBlastTab(qR, searchOptions)
+			queryWriter <- []byte(output)
+		}
+
 		// Write respopnse tsv + no alignement
 		if searchOptions.OutFormat == "tsv" && !searchOptions.Align {
 			for _, h := range qR.SearchResults.Hits {
@@ -517,19 +807,19 @@ func QueryResultHandler(queryResult <-chan QueryResult, queryWriter chan<- []byt
 					output += "N/A"
 				}
 				output += "\t"
-				output += strconv.Itoa(qR.Query.Location.StartPosition)
+				qStart, qEnd, sStart, sEnd := qR.Span(h)
+				if searchOptions.SequenceType != PROTEIN {
+					qStart, qEnd = qR.Query.Location.StartPosition, qR.Query.Location.EndPosition
+				}
+				output += strconv.Itoa(qStart)
+				output += "\t"
+				output += strconv.Itoa(qEnd)
 				output += "\t"
-				output += strconv.Itoa(qR.Query.Location.EndPosition)
+				output += strconv.Itoa(sStart)
 				output += "\t"
-				output += "1" // subject always start at 1 in kmer
+				output += strconv.Itoa(sEnd)
 				output += "\t"
-
-				// Only know subject lenght when adding annotation
-				if searchOptions.Annotations {
-					output += fmt.Sprintf("%d", qR.HitEntries[h.Key].Length)
-				} else {
-					output += "N/A"
-				}
+				output += coverage(sStart, sEnd, int(qR.HitEntries[h.Key].Length))
 				if searchOptions.ExtractPositions {
 					output += "\t"
 					output += posString
@@ -541,6 +831,9 @@ func QueryResultHandler(queryResult <-chan QueryResult, queryWriter chan<- []byt
 						output += qR.HitEntries[h.Key].Features[annotation]
 					}
 				}
+				if searchOptions.LCA {
+					output += FormatLCAColumns(qR.LCA)
+				}
 				output += "\n"
 				queryWriter <- []byte(output)
 			}
@@ -591,6 +884,9 @@ func QueryResultHandler(queryResult <-chan QueryResult, queryWriter chan<- []byt
 						output += qR.HitEntries[h.Key].Features[annotation]
 					}
 				}
+				if searchOptions.LCA {
+					output += FormatLCAColumns(qR.LCA)
+				}
 				output += "\n"
 				queryWriter <- []byte(output)
 			}
@@ -601,12 +897,23 @@ func QueryResultHandler(queryResult <-chan QueryResult, queryWriter chan<- []byt
 
 }
 
-func QueryResultWriter(queryResultOutput <-chan []byte, w http.ResponseWriter, wg *sync.WaitGroup) {
+// QueryResultWriter writes the results received from queryResultOutput to w.
+// The response header is written with the first result (started is then set)
+// so a search failing before any result can still get an error status.
+func QueryResultWriter(ctx context.Context, queryResultOutput <-chan []byte, w http.ResponseWriter, searchOptions SearchOptions, kvStores *kvstore.KVStores, dbStats kvstore.KStats, started *bool, wg *sync.WaitGroup) {
 
 	defer wg.Done()
-	SetResponseFormatAndHeader(w)
 	firstResult := true
+	iterationNum := 0
 	for output := range queryResultOutput {
+		// client is gone, only drain the results
+		if ctx.Err() != nil {
+			continue
+		}
+		if !*started {
+			SetResponseFormatAndHeader(w, searchOptions, kvStores, dbStats)
+			*started = true
+		}
 		// Write respopnse json
 		if searchOptions.OutFormat == "json" {
 			if !firstResult {
@@ -614,18 +921,21 @@ func QueryResultWriter(queryResultOutput <-chan []byte, w http.ResponseWriter, w
 			}
 			w.Write(output)
 			firstResult = false
-		} else if searchOptions.OutFormat == "tsv" {
+		} else if searchOptions.OutFormat == "xml" {
+			iterationNum++
+			w.Write([]byte(FormatXMLIterationStart(iterationNum)))
+			w.Write(output)
+		} else {
 			w.Write(output)
 		}
 	}
-	if searchOptions.OutFormat == "json" {
-		// open results array
-		w.Write([]byte("]}"))
-	}
 
 }
 
-func SetResponseFormatAndHeader(w http.ResponseWriter) {
+func SetResponseFormatAndHeader(w http.ResponseWriter, searchOptions SearchOptions, kvStores *kvstore.KVStores, dbStats kvstore.KStats) {
+
+	// the timeout and errors are only known once the results are written
+	w.Header().Set("Trailer", TIMEOUT_TRAILER+", "+ERROR_TRAILER)
 
 	// Set output response header TSV
 	if searchOptions.OutFormat == "tsv" {
@@ -634,8 +944,13 @@ func SetResponseFormatAndHeader(w http.ResponseWriter) {
 		w.Header().Set("Content-Type", "text/plain;charset=UTF-8")
 		w.WriteHeader(200)
 
+		// the abundance summary has its own header
+		if searchOptions.Summary == SUMMARY_ONLY {
+			return
+		}
+
 		if !searchOptions.Align {
-			w.Write([]byte("QueryId\tSubjectId\t%KMatchIdentity\tQueryKLength\tKMatch\tGapOpen\tQStart\tQEnd\tSStart\tSEnd"))
+			w.Write([]byte("QueryId\tSubjectId\t%KMatchIdentity\tQueryKLength\tKMatch\tGapOpen\tQStart\tQEnd\tSStart\tSEnd\t%SCoverage"))
 		} else {
 			// TSV output for alignment
 			w.Write([]byte("QueryId\tSubjectId\t%Identity\tAlnLength\tMismatches\tGapOpen\tQStart\tQEnd\tSStart\tSEnd\tEvalue\tBitscore"))
@@ -649,10 +964,97 @@ func SetResponseFormatAndHeader(w http.ResponseWriter) {
 				w.Write([]byte(annotation))
 			}
 		}
+		if searchOptions.LCA {
+			w.Write([]byte("\tLCATaxon\tLCATaxId\tLCALineage"))
+		}
 		w.Write([]byte("\n"))
 
 	}
 
+	// Set output response header gff
+	if searchOptions.OutFormat == "gff" {
+
+		// set http response header
+		w.Header().Set("Content-Type", "text/x-gff3;charset=UTF-8")
+		w.WriteHeader(200)
+
+		w.Write([]byte(GFF_HEADER))
+
+	}
+
+	// Set output response header ndjson
+	if searchOptions.OutFormat == "ndjson" {
+
+		// set http response header
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(200)
+
+		if searchOptions.Metadata {
+			metadata := ResultsMetadata{
+				DBProteinFeatures: []string{},
+				KmerRatio:         searchOptions.KMatchRatio,
+				MinKmerHits:       searchOptions.MinKMatch,
+			}
+			if searchOptions.Annotations {
+				metadata.DBProteinFeatures = dbStats.Features
+			}
+			data, _ := json.Marshal(metadata)
+			w.Write(append(data, '\n'))
+		}
+
+	}
+
+	// Set output response header fasta
+	if searchOptions.OutFormat == "fasta" {
+
+		// set http response header
+		w.Header().Set("Content-Type", "text/plain;charset=UTF-8")
+		w.WriteHeader(200)
+
+	}
+
+	// Set output response header sam
+	if searchOptions.OutFormat == "sam" {
+
+		// set http response header
+		w.Header().Set("Content-Type", "text/plain;charset=UTF-8")
+		w.WriteHeader(200)
+
+		if err := WriteSAMHeader(w, kvStores); err != nil {
+			fmt.Println(err.Error())
+		}
+
+	}
+
+	// Set output response header blast xml
+	if searchOptions.OutFormat == "xml" {
+
+		// set http response header
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(200)
+
+		w.Write([]byte(FormatXMLHeader(dbStats)))
+
+	}
+
+	// Set output response header blast tabular (comments are per query in blast7)
+	if BlastFormats[searchOptions.OutFormat] {
+
+		// set http response header
+		w.Header().Set("Content-Type", "text/plain;charset=UTF-8")
+		w.WriteHeader(200)
+
+	}
+
+	// Set output response header genbank / embl
+	if RecordFormats[searchOptions.OutFormat] {
+
+		// set http response header
+		w.Header().Set("Content-Type", "text/plain;charset=UTF-8")
+		w.WriteHeader(200)
+
+	}
+
 	// Set output response header json
 	if searchOptions.OutFormat == "json" {
 
@@ -676,6 +1078,10 @@ func SetResponseFormatAndHeader(w http.ResponseWriter) {
 			}
 		}
 		w.Write([]byte("]"))
+		w.Write([]byte(",\"kmerRatio\":"))
+		w.Write([]byte(strconv.FormatFloat(searchOptions.KMatchRatio, 'f', -1, 64)))
+		w.Write([]byte(",\"minKmerHits\":"))
+		w.Write([]byte(strconv.FormatInt(searchOptions.MinKMatch, 10)))
 		w.Write([]byte(",\"results\":"))
 		w.Write([]byte("["))
 
@@ -683,6 +1089,53 @@ func SetResponseFormatAndHeader(w http.ResponseWriter) {
 
 }
 
+// SetResponseFooter closes the response document, with the abundance summary
+// if not nil, and flags results that are partial because the search timed out
+func SetResponseFooter(w http.ResponseWriter, searchOptions SearchOptions, timedOut bool, abundance *Abundance) {
+
+	w.Header().Set(TIMEOUT_TRAILER, strconv.FormatBool(timedOut))
+
+	if abundance != nil {
+		summary := abundance.Summary()
+		switch searchOptions.OutFormat {
+		case "tsv":
+			if searchOptions.Summary == SUMMARY_APPEND {
+				w.Write([]byte("# abundance summary\n"))
+			}
+			w.Write([]byte(FormatAbundanceTSV(summary)))
+		case "ndjson":
+			data, _ := json.Marshal(map[string]AbundanceSummary{"summary": summary})
+			w.Write(append(data, '\n'))
+		}
+	}
+
+	if (searchOptions.OutFormat == "tsv" || searchOptions.OutFormat == "gff" || searchOptions.OutFormat == "blast7") && timedOut {
+		w.Write([]byte("# search timed out, results are partial\n"))
+	}
+
+	if searchOptions.OutFormat == "xml" {
+		w.Write([]byte(FormatXMLFooter(timedOut)))
+	}
+
+	if searchOptions.OutFormat == "ndjson" && timedOut {
+		w.Write([]byte("{\"timedOut\":true}\n"))
+	}
+
+	if searchOptions.OutFormat == "json" {
+		// close results array
+		w.Write([]byte("]"))
+		if abundance != nil {
+			data, _ := json.Marshal(abundance.Summary())
+			w.Write([]byte(",\"summary\":"))
+			w.Write(data)
+		}
+		w.Write([]byte(",\"timedOut\":"))
+		w.Write([]byte(strconv.FormatBool(timedOut)))
+		w.Write([]byte("}"))
+	}
+
+}
+
 func FormatPositionsToString(positions []bool) string {
 
 	currentStart := 0
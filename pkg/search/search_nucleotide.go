@@ -17,45 +17,15 @@ limitations under the License.
 package search
 
 import (
-	"net/http"
+	"context"
+	"strings"
 	"sync"
 
 	cnt "github.com/zorino/counters"
 	"github.com/zorino/kaamer/pkg/kvstore"
 )
 
-func NucleotideSearch(searchOptions SearchOptions, kvStores *kvstore.KVStores, nbOfThreads int, w http.ResponseWriter, fastq bool, cancelQuery *bool) {
-
-	file := searchOptions.File
-
-	queryChan := make(chan Query)
-
-	wgReader := new(sync.WaitGroup)
-	wgReader.Add(1)
-
-	go func() {
-		defer wgReader.Done()
-		if fastq {
-			GetQueriesFastq(file, queryChan, cancelQuery)
-		} else {
-			GetQueriesFasta(file, queryChan, false, cancelQuery)
-		}
-		close(queryChan)
-	}()
-
-	// Single query results writer
-	queryWriterChan := make(chan []byte, 10)
-	wgResWriter := new(sync.WaitGroup)
-	wgResWriter.Add(1)
-	go QueryResultWriter(queryWriterChan, w, wgResWriter)
-
-	// Concurrent query result handlers
-	queryResultChan := make(chan QueryResult, 10)
-	wgResHandler := new(sync.WaitGroup)
-	for i := 0; i < nbOfThreads; i++ {
-		wgResHandler.Add(1)
-		go QueryResultHandler(queryResultChan, queryWriterChan, w, wgResHandler)
-	}
+func NucleotideSearch(ctx context.Context, searchOptions SearchOptions, kvStores *kvstore.KVStores, dbStats kvstore.KStats, nbOfThreads int, queryChan <-chan Query, queryResultChan chan<- QueryResult, fastq bool) {
 
 	wgSearch := new(sync.WaitGroup)
 
@@ -68,25 +38,50 @@ func NucleotideSearch(searchOptions SearchOptions, kvStores *kvstore.KVStores, n
 			defer wgSearch.Done()
 			searchRes := new(SearchResults)
 			keyChan := make(chan KeyPos, 10)
+			kmerSpan := kvStores.KmerStore.Scheme.Span()
+
+			// align and send a query result, false if the search is cancelled
+			emitResult := func(qR QueryResult) bool {
+				if searchOptions.Align {
+					if err := qR.AlignAndFilterHits(ctx, searchOptions, dbStats); err != nil {
+						return false
+					}
+					// no hit passed the alignment cut-offs
+					if qR.SearchResults.Hits.Len() == 0 {
+						return true
+					}
+				}
+				if searchOptions.LCA && qR.LCA == nil {
+					qR.LCA = GetLCA([]QueryResult{qR}, searchOptions.LCAWindow, searchOptions.Align)
+				}
+				return sendQueryResult(ctx, queryResultChan, qR)
+			}
+
+			// ORF results are kept until the whole contig (or read) is searched
+			// to resolve the overlapping ORFs of contigs or to get the LCA of reads
+			resolveORFs := searchOptions.ResolveORFs && !fastq
+			readLCA := searchOptions.LCA && fastq
 
 			for s := range queryChan {
 
-				orfs := GetORFs(s.Sequence, searchOptions.GeneticCode)
+				orfs, err := GetORFs(s.Sequence, searchOptions.GeneticCode, searchOptions.ORFMode, searchOptions.MinORFLength)
+				if err != nil {
+					return
+				}
+
+				contigResults := []QueryResult{}
 
 				for _, o := range orfs {
 
 					q := Query{
 						Sequence:   o.Sequence,
 						Name:       s.Name,
-						SizeInKmer: (len(o.Sequence)) - KMER_SIZE + 1,
+						SizeInKmer: searchedKmers(o.Sequence, kmerSpan),
 						Location:   o.Location,
 						Contig:     s.Contig,
 						Type:       DNA_QUERY,
 					}
-
-					if q.Sequence[len(q.Sequence)-1:] == "*" {
-						q.SizeInKmer = q.SizeInKmer - 1
-					}
+					nbWindows := len(q.Sequence) - kmerSpan + 1
 
 					searchRes = new(SearchResults)
 					searchRes.Counter = cnt.NewCounterBox()
@@ -100,11 +95,15 @@ func NucleotideSearch(searchOptions SearchOptions, kvStores *kvstore.KVStores, n
 
 					wg := new(sync.WaitGroup)
 					wg.Add(1)
-					go searchRes.KmerSearch(keyChan, kvStores, wg, matchPositionChan)
+					go searchRes.KmerSearch(ctx, keyChan, kvStores, dbStats, searchOptions, wg, matchPositionChan)
 
-					for i := 0; i < q.SizeInKmer; i++ {
-						key := kvStores.KmerStore.CreateBytesKey(q.Sequence[i : i+KMER_SIZE])
-						keyChan <- KeyPos{Key: key, Pos: i, QSize: q.SizeInKmer}
+					for i := 0; i < nbWindows && ctx.Err() == nil; i++ {
+						// six-frame translations keep their stop codons
+						if strings.IndexByte(q.Sequence[i:i+kmerSpan], '*') >= 0 {
+							continue
+						}
+						key := kvStores.KmerStore.CreateBytesKey(q.Sequence[i : i+kmerSpan])
+						keyChan <- KeyPos{Key: key, Pos: i, QSize: nbWindows}
 					}
 
 					close(keyChan)
@@ -113,36 +112,88 @@ func NucleotideSearch(searchOptions SearchOptions, kvStores *kvstore.KVStores, n
 					wgMP.Wait()
 
 					searchRes.Hits = sortMapByValue(searchRes.Counter.GetCountersMap())
-					if len(searchRes.Hits) > 0 && searchRes.Hits[0].Kmatch >= minKMatch {
+					if searchOptions.Scoring == SCORING_IDF {
+						searchRes.Hits.UnscaleWeights()
+					}
+					if len(searchRes.Hits) > 0 && searchRes.Hits[0].Kmatch >= searchOptions.MinKMatch {
 						qR := QueryResult{Query: q, SearchResults: searchRes, HitEntries: map[uint32]kvstore.Protein{}}
 						SetBestStartCodon(&qR)
-						qR.FilterResults()
+						if searchOptions.Scoring == SCORING_DIAGONAL {
+							qR.ScoreDiagonals(kvStores, searchOptions)
+						}
+						qR.FilterResults(searchOptions)
 						if qR.SearchResults.Hits.Len() > 0 {
 							qR.FetchHitsInformation(kvStores)
-							queryResultChan <- qR
+							if !searchOptions.Align {
+								qR.SetKmerSpans(kvStores.KmerStore.Scheme)
+							}
+							if resolveORFs || readLCA {
+								contigResults = append(contigResults, qR)
+							} else if !emitResult(qR) {
+								return
+							}
 						}
 					}
 
-					if *cancelQuery {
+					if ctx.Err() != nil {
 						return
 					}
 
 				}
 
+				if resolveORFs {
+					contigResults = ResolveORFs(contigResults, searchOptions.MaxORFOverlap)
+				}
+
+				if readLCA && len(contigResults) > 0 {
+					if searchOptions.Align {
+						alignedResults := []QueryResult{}
+						for _, qR := range contigResults {
+							if err := qR.AlignAndFilterHits(ctx, searchOptions, dbStats); err != nil {
+								return
+							}
+							if qR.SearchResults.Hits.Len() > 0 {
+								alignedResults = append(alignedResults, qR)
+							}
+						}
+						contigResults = alignedResults
+					}
+					lca := GetLCA(contigResults, searchOptions.LCAWindow, searchOptions.Align)
+					for i := range contigResults {
+						contigResults[i].LCA = lca
+					}
+					for _, qR := range contigResults {
+						if !sendQueryResult(ctx, queryResultChan, qR) {
+							return
+						}
+					}
+				} else if resolveORFs {
+					for _, qR := range contigResults {
+						if !emitResult(qR) {
+							return
+						}
+					}
+				}
+
 			}
 
 		}()
 
 	}
 
-	wgReader.Wait()
-
 	wgSearch.Wait()
-	close(queryResultChan)
 
-	wgResHandler.Wait()
-
-	close(queryWriterChan)
-	wgResWriter.Wait()
+}
 
+// searchedKmers returns the number of kmer windows of an ORF that are
+// searched : the windows overlapping a stop codon (the trailing one or the
+// internal ones of six-frame translations) are not
+func searchedKmers(sequence string, kmerSpan int) int {
+	nbKmers := 0
+	for i := 0; i+kmerSpan <= len(sequence); i++ {
+		if strings.IndexByte(sequence[i:i+kmerSpan], '*') < 0 {
+			nbKmers++
+		}
+	}
+	return nbKmers
 }
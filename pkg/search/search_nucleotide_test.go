@@ -0,0 +1,37 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"testing"
+)
+
+func TestSearchedKmersInternalStop(t *testing.T) {
+
+	// 20 residues : 14 windows of 7, the 7 windows overlapping the stop are skipped
+	if n := searchedKmers("MKVLAAGIV*LLLAAQPAMA", 7); n != 7 {
+		t.Errorf("ORF with an internal stop has %d searched kmers, expected 7", n)
+	}
+	// the trailing stop only removes the last window
+	if n := searchedKmers("MKVLAAGIVGLLLAAQPAM*", 7); n != 13 {
+		t.Errorf("ORF with a trailing stop has %d searched kmers, expected 13", n)
+	}
+	if n := searchedKmers("MKVLA", 7); n != 0 {
+		t.Errorf("ORF shorter than a kmer has %d searched kmers, expected 0", n)
+	}
+
+}
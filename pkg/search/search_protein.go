@@ -17,41 +17,14 @@ limitations under the License.
 package search
 
 import (
-	"net/http"
+	"context"
 	"sync"
 
 	cnt "github.com/zorino/counters"
 	"github.com/zorino/kaamer/pkg/kvstore"
 )
 
-func ProteinSearch(searchOptions SearchOptions, kvStores *kvstore.KVStores, nbOfThreads int, w http.ResponseWriter, cancelQuery *bool) {
-
-	file := searchOptions.File
-
-	queryChan := make(chan Query, 5)
-
-	wgReader := new(sync.WaitGroup)
-	wgReader.Add(1)
-
-	go func() {
-		defer wgReader.Done()
-		GetQueriesFasta(file, queryChan, true, cancelQuery)
-		close(queryChan)
-	}()
-
-	// Single query results writer
-	queryWriterChan := make(chan []byte, 10)
-	wgResWriter := new(sync.WaitGroup)
-	wgResWriter.Add(1)
-	go QueryResultWriter(queryWriterChan, w, wgResWriter)
-
-	// Concurrent query result handlers
-	queryResultChan := make(chan QueryResult, 10)
-	wgResHandler := new(sync.WaitGroup)
-	for i := 0; i < nbOfThreads; i++ {
-		wgResHandler.Add(1)
-		go QueryResultHandler(queryResultChan, queryWriterChan, w, wgResHandler)
-	}
+func ProteinSearch(ctx context.Context, searchOptions SearchOptions, kvStores *kvstore.KVStores, dbStats kvstore.KStats, nbOfThreads int, queryChan <-chan Query, queryResultChan chan<- QueryResult) {
 
 	wgSearch := new(sync.WaitGroup)
 
@@ -66,13 +39,14 @@ func ProteinSearch(searchOptions SearchOptions, kvStores *kvstore.KVStores, nbOf
 			queryResult := QueryResult{}
 			searchRes := new(SearchResults)
 			keyChan := make(chan KeyPos, 20)
+			kmerSpan := kvStores.KmerStore.Scheme.Span()
 
 			for q := range queryChan {
 
 				q.Type = PROTEIN_QUERY
 
-				if q.SizeInKmer < 7 {
-					return
+				if q.SizeInKmer < 1 {
+					continue
 				}
 
 				searchRes = new(SearchResults)
@@ -89,10 +63,10 @@ func ProteinSearch(searchOptions SearchOptions, kvStores *kvstore.KVStores, nbOf
 				keyChan = make(chan KeyPos, 10)
 				_wg := new(sync.WaitGroup)
 				_wg.Add(1)
-				go searchRes.KmerSearch(keyChan, kvStores, _wg, matchPositionChan)
+				go searchRes.KmerSearch(ctx, keyChan, kvStores, dbStats, searchOptions, _wg, matchPositionChan)
 
-				for k := 0; k < q.SizeInKmer; k++ {
-					key := kvStores.KmerStore.CreateBytesKey(q.Sequence[k : k+KMER_SIZE])
+				for k := 0; k < q.SizeInKmer && ctx.Err() == nil; k++ {
+					key := kvStores.KmerStore.CreateBytesKey(q.Sequence[k : k+kmerSpan])
 					keyChan <- KeyPos{Key: key, Pos: k, QSize: q.SizeInKmer}
 				}
 
@@ -102,12 +76,35 @@ func ProteinSearch(searchOptions SearchOptions, kvStores *kvstore.KVStores, nbOf
 				wgMP.Wait()
 
 				searchRes.Hits = sortMapByValue(searchRes.Counter.GetCountersMap())
+				if searchOptions.Scoring == SCORING_IDF {
+					searchRes.Hits.UnscaleWeights()
+				}
 
 				queryResult = QueryResult{Query: q, SearchResults: searchRes, HitEntries: map[uint32]kvstore.Protein{}}
-				queryResult.FilterResults()
+				if searchOptions.Scoring == SCORING_DIAGONAL {
+					queryResult.ScoreDiagonals(kvStores, searchOptions)
+				}
+				queryResult.FilterResults(searchOptions)
 				if queryResult.SearchResults.Hits.Len() > 0 {
 					queryResult.FetchHitsInformation(kvStores)
-					queryResultChan <- queryResult
+					if !searchOptions.Align {
+						queryResult.SetKmerSpans(kvStores.KmerStore.Scheme)
+					}
+					if searchOptions.Align {
+						if err := queryResult.AlignAndFilterHits(ctx, searchOptions, dbStats); err != nil {
+							return
+						}
+						// no hit passed the alignment cut-offs
+						if queryResult.SearchResults.Hits.Len() == 0 {
+							continue
+						}
+					}
+					if searchOptions.LCA {
+						queryResult.LCA = GetLCA([]QueryResult{queryResult}, searchOptions.LCAWindow, searchOptions.Align)
+					}
+					if !sendQueryResult(ctx, queryResultChan, queryResult) {
+						return
+					}
 				}
 
 			}
@@ -116,15 +113,6 @@ func ProteinSearch(searchOptions SearchOptions, kvStores *kvstore.KVStores, nbOf
 
 	}
 
-	wgReader.Wait()
-
 	wgSearch.Wait()
-	close(queryResultChan)
-
-	wgResHandler.Wait()
-
-	close(queryWriterChan)
-
-	wgResWriter.Wait()
 
 }
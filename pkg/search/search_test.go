@@ -0,0 +1,97 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger/options"
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+func TestFilterResultsBestHitsTies(t *testing.T) {
+
+	searchOptions := DefaultSearchOptions()
+	searchOptions.MaxResults = 1
+	searchOptions.BestHits = true
+
+	qR := QueryResult{
+		Query:         Query{SizeInKmer: 50},
+		SearchResults: &SearchResults{Hits: HitList{{Key: 1, Kmatch: 40}, {Key: 2, Kmatch: 40}, {Key: 3, Kmatch: 30}}},
+	}
+	qR.FilterResults(searchOptions)
+
+	if len(qR.SearchResults.Hits) != 2 {
+		t.Errorf("-best -m 1 kept %d hits, expected the 2 tied best hits", len(qR.SearchResults.Hits))
+	}
+
+}
+
+func TestFilterResultsTopBeforeMaxResults(t *testing.T) {
+
+	searchOptions := DefaultSearchOptions()
+	searchOptions.MaxResults = 2
+	searchOptions.TopPercent = 50
+
+	qR := QueryResult{
+		Query:         Query{SizeInKmer: 50},
+		SearchResults: &SearchResults{Hits: HitList{{Key: 1, Kmatch: 40}, {Key: 2, Kmatch: 30}, {Key: 3, Kmatch: 25}, {Key: 4, Kmatch: 10}}},
+	}
+	qR.FilterResults(searchOptions)
+
+	if len(qR.SearchResults.Hits) != 2 || qR.SearchResults.Hits[1].Key != 2 {
+		t.Errorf("-top 50 -m 2 kept %+v, expected the hits 1 and 2", qR.SearchResults.Hits)
+	}
+
+}
+
+func TestNewSearchResultQueryError(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "kaamer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a valid query followed by a sequence line longer than the scanner buffer
+	queryFile := filepath.Join(dir, "query.fasta")
+	queries := ">q1\nMKVLAAGIVGLLLAAQPAMA\n>q2\n" + strings.Repeat("A", 2*1024*1024) + "\n"
+	if err := ioutil.WriteFile(queryFile, []byte(queries), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	kvStores := kvstore.KVStoresNew(dir, 1, options.MemoryMap, options.MemoryMap, false, false, false)
+	defer kvStores.Close()
+	searcher := NewSearcher(kvStores, kvstore.KStats{}, 1)
+
+	searchOptions := DefaultSearchOptions()
+	searchOptions.File = queryFile
+	searchOptions.InputType = "path"
+
+	w := httptest.NewRecorder()
+	NewSearchResult(searchOptions, searcher, w, httptest.NewRequest("POST", "/api/search/protein", nil))
+
+	if w.Code != 400 || !strings.Contains(w.Body.String(), "token too long") {
+		t.Errorf("Malformed query stream returned %d : %s", w.Code, w.Body.String())
+	}
+
+}
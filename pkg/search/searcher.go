@@ -0,0 +1,181 @@
+/*
+Copyright 2019 The kaamer Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package search
+
+import (
+	"context"
+	"io"
+
+	"github.com/zorino/kaamer/pkg/kvstore"
+)
+
+// Searcher runs searches against an opened kAAmer database and hands back
+// typed QueryResult values, independently of any output format.
+type Searcher struct {
+	KVStores    *kvstore.KVStores
+	DBStats     kvstore.KStats
+	NbOfThreads int
+}
+
+func NewSearcher(kvStores *kvstore.KVStores, dbStats kvstore.KStats, nbOfThreads int) *Searcher {
+
+	if nbOfThreads < 1 {
+		nbOfThreads = 1
+	}
+
+	return &Searcher{
+		KVStores:    kvStores,
+		DBStats:     dbStats,
+		NbOfThreads: nbOfThreads,
+	}
+
+}
+
+// Search sends every QueryResult found for the queries in searchOptions.File
+// to queryResultChan and closes the channel once the search is done.
+// Hits are already filtered, annotated and aligned (if searchOptions.Align).
+// Options are local to the search so concurrent searches are independent.
+//
+// The search stops early when ctx is done or searchOptions.Timeout is
+// reached ; results sent so far are kept and the context error is returned
+// (context.DeadlineExceeded on timeout).
+// Nucleotide and reads queries are translated with searchOptions.GeneticCode
+// into ORFs called with searchOptions.ORFMode.
+// Invalid options or an unreadable query file are returned as errors.
+func (s *Searcher) Search(ctx context.Context, searchOptions SearchOptions, queryResultChan chan<- QueryResult) error {
+
+	input, err := OpenQueries(searchOptions.File)
+	if err != nil {
+		close(queryResultChan)
+		return err
+	}
+	defer input.Close()
+
+	return s.SearchReader(ctx, searchOptions, input, queryResultChan)
+
+}
+
+// SearchReader is like Search for the fasta (or fastq for READS) queries
+// read from r, plain text or gzipped
+func (s *Searcher) SearchReader(ctx context.Context, searchOptions SearchOptions, r io.Reader, queryResultChan chan<- QueryResult) error {
+
+	reader, err := NewQueryReader(r)
+	if err != nil {
+		close(queryResultChan)
+		return err
+	}
+
+	kmerSpan := s.KVStores.KmerStore.Scheme.Span()
+
+	return s.search(ctx, searchOptions, func(ctx context.Context, queryChan chan<- Query) error {
+		if searchOptions.SequenceType == READS {
+			return GetQueriesFastq(ctx, reader, queryChan, kmerSpan)
+		}
+		return GetQueriesFasta(ctx, reader, queryChan, searchOptions.SequenceType == PROTEIN, kmerSpan)
+	}, queryResultChan)
+
+}
+
+// SearchQueries is like Search for in-memory queries, only their Name and
+// Sequence are needed
+func (s *Searcher) SearchQueries(ctx context.Context, searchOptions SearchOptions, queries []Query, queryResultChan chan<- QueryResult) error {
+
+	kmerSpan := s.KVStores.KmerStore.Scheme.Span()
+
+	return s.search(ctx, searchOptions, func(ctx context.Context, queryChan chan<- Query) error {
+		for _, q := range queries {
+			if !sendQuery(ctx, queryChan, NewQuery(q.Name, q.Sequence, searchOptions.SequenceType, kmerSpan)) {
+				break
+			}
+		}
+		return nil
+	}, queryResultChan)
+
+}
+
+// search runs the search of the queries sent by readQueries
+func (s *Searcher) search(ctx context.Context, searchOptions SearchOptions, readQueries func(context.Context, chan<- Query) error, queryResultChan chan<- QueryResult) error {
+
+	defer close(queryResultChan)
+
+	if searchOptions.SequenceType != PROTEIN {
+		if err := CheckORFOptions(searchOptions.GeneticCode, searchOptions.ORFMode, searchOptions.MinORFLength); err != nil {
+			return err
+		}
+	}
+
+	if searchOptions.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, searchOptions.Timeout)
+		defer cancel()
+	}
+
+	queryChan := make(chan Query, 5)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- readQueries(ctx, queryChan)
+		close(queryChan)
+	}()
+
+	switch searchOptions.SequenceType {
+	case READS:
+		NucleotideSearch(ctx, searchOptions, s.KVStores, s.DBStats, s.NbOfThreads, queryChan, queryResultChan, true)
+	case NUCLEOTIDE:
+		NucleotideSearch(ctx, searchOptions, s.KVStores, s.DBStats, s.NbOfThreads, queryChan, queryResultChan, false)
+	case PROTEIN:
+		ProteinSearch(ctx, searchOptions, s.KVStores, s.DBStats, s.NbOfThreads, queryChan, queryResultChan)
+	}
+
+	// queries left by a cancelled search
+	for range queryChan {
+	}
+
+	if err := <-readErr; err != nil {
+		return err
+	}
+
+	return ctx.Err()
+
+}
+
+// SearchFunc is like Search but calls fn for each QueryResult, from a
+// single goroutine, and returns when the search is done.
+func (s *Searcher) SearchFunc(ctx context.Context, searchOptions SearchOptions, fn func(QueryResult)) error {
+
+	queryResultChan := make(chan QueryResult, 10)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.Search(ctx, searchOptions, queryResultChan)
+	}()
+
+	for qR := range queryResultChan {
+		fn(qR)
+	}
+
+	return <-errChan
+
+}
+
+// sendQueryResult sends queryResult to queryResultChan unless ctx is done first
+func sendQueryResult(ctx context.Context, queryResultChan chan<- QueryResult, queryResult QueryResult) bool {
+	select {
+	case queryResultChan <- queryResult:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
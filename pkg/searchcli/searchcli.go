@@ -17,7 +17,6 @@ limitations under the License.
 package searchcli
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	"io"
@@ -27,6 +26,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/zorino/kaamer/pkg/search"
 )
@@ -38,18 +38,20 @@ type SearchRequestOptions struct {
 	search.SearchOptions
 }
 
+// MatrixRequestOptions are the options of a multi-sample reads search
+type MatrixRequestOptions struct {
+	SearchRequestOptions
+	Samples []search.Sample
+	Feature string
+}
+
 func NewSearchRequest(options SearchRequestOptions) {
 
 	bodyBuf := &bytes.Buffer{}
 	bodyWriter := multipart.NewWriter(bodyBuf)
 
 	bodyWriter.WriteField("type", options.InputType)
-	bodyWriter.WriteField("gcode", strconv.Itoa(options.GeneticCode))
-	bodyWriter.WriteField("output-format", options.OutFormat)
-	bodyWriter.WriteField("max-results", strconv.Itoa(options.MaxResults))
-	bodyWriter.WriteField("align", strconv.FormatBool(options.Align))
-	bodyWriter.WriteField("annotations", strconv.FormatBool(options.Annotations))
-	bodyWriter.WriteField("positions", strconv.FormatBool(options.ExtractPositions))
+	writeSearchFields(bodyWriter, options)
 
 	host := options.ServerHost + "/api/search/"
 	switch options.SequenceType {
@@ -62,24 +64,101 @@ func NewSearchRequest(options SearchRequestOptions) {
 	}
 
 	if options.InputType == "file" {
+		writeFile(bodyWriter, options.File)
+	} else if options.InputType == "path" {
+		bodyWriter.WriteField("file", options.File)
+	}
 
-		fileWriter, err := bodyWriter.CreateFormFile("file", options.File)
+	contentType := bodyWriter.FormDataContentType()
+	bodyWriter.Close()
 
-		dat, err := ioutil.ReadFile(options.File)
+	postRequest(host, contentType, bodyBuf, options)
 
-		if err != nil {
-			log.Fatal(err.Error())
-		}
+}
 
-		fileWriter.Write(dat)
+// NewMatrixRequest requests the feature by sample read count matrix of the samples
+func NewMatrixRequest(options MatrixRequestOptions) {
 
-	} else if options.InputType == "path" {
-		bodyWriter.WriteField("file", options.File)
+	bodyBuf := &bytes.Buffer{}
+	bodyWriter := multipart.NewWriter(bodyBuf)
+
+	bodyWriter.WriteField("type", options.InputType)
+	bodyWriter.WriteField("feature", options.Feature)
+	writeSearchFields(bodyWriter, options.SearchRequestOptions)
+
+	for _, sample := range options.Samples {
+		// the server names the samples by file name otherwise
+		if sample.Name != "" {
+			bodyWriter.WriteField("sample", sample.Name)
+		}
+		if options.InputType == "file" {
+			writeFile(bodyWriter, sample.File)
+		} else {
+			bodyWriter.WriteField("file", sample.File)
+		}
 	}
 
 	contentType := bodyWriter.FormDataContentType()
 	bodyWriter.Close()
 
+	resp := postRequest(options.ServerHost+"/api/search/matrix", contentType, bodyBuf, options.SearchRequestOptions)
+
+	if timedOut := resp.Header.Get(search.MATRIX_TIMEOUT_HEADER); timedOut != "" {
+		fmt.Fprintf(os.Stderr, "Search timed out, counts are partial for samples : %s\n", timedOut)
+	}
+
+}
+
+// writeSearchFields writes the search parameters (all but the input) to the request form
+func writeSearchFields(bodyWriter *multipart.Writer, options SearchRequestOptions) {
+
+	bodyWriter.WriteField("gcode", strconv.Itoa(options.GeneticCode))
+	bodyWriter.WriteField("orf-mode", options.ORFMode)
+	bodyWriter.WriteField("orf-min-length", strconv.Itoa(options.MinORFLength))
+	bodyWriter.WriteField("resolve-orfs", strconv.FormatBool(options.ResolveORFs))
+	bodyWriter.WriteField("max-overlap", strconv.Itoa(options.MaxORFOverlap))
+	bodyWriter.WriteField("output-format", options.OutFormat)
+	bodyWriter.WriteField("columns", strings.Join(options.OutColumns, " "))
+	bodyWriter.WriteField("metadata", strconv.FormatBool(options.Metadata))
+	bodyWriter.WriteField("hit-sequences", strconv.FormatBool(options.HitSequences))
+	bodyWriter.WriteField("lca", strconv.FormatBool(options.LCA))
+	bodyWriter.WriteField("summary", options.Summary)
+	bodyWriter.WriteField("lca-window", strconv.FormatFloat(options.LCAWindow, 'f', -1, 64))
+	bodyWriter.WriteField("max-results", strconv.Itoa(options.MaxResults))
+	bodyWriter.WriteField("top", strconv.FormatFloat(options.TopPercent, 'f', -1, 64))
+	bodyWriter.WriteField("best-hits", strconv.FormatBool(options.BestHits))
+	bodyWriter.WriteField("scoring", options.Scoring)
+	bodyWriter.WriteField("kmer-ratio", strconv.FormatFloat(options.KMatchRatio, 'f', -1, 64))
+	bodyWriter.WriteField("min-kmer-hits", strconv.FormatInt(options.MinKMatch, 10))
+	bodyWriter.WriteField("timeout", strconv.Itoa(int(options.Timeout.Seconds())))
+	bodyWriter.WriteField("align", strconv.FormatBool(options.Align))
+	bodyWriter.WriteField("max-evalue", strconv.FormatFloat(options.MaxEValue, 'g', -1, 64))
+	bodyWriter.WriteField("min-identity", strconv.FormatFloat(options.MinIdentity, 'f', -1, 64))
+	bodyWriter.WriteField("min-query-cover", strconv.FormatFloat(options.MinQueryCover, 'f', -1, 64))
+	bodyWriter.WriteField("min-subject-cover", strconv.FormatFloat(options.MinSubjectCover, 'f', -1, 64))
+	bodyWriter.WriteField("annotations", strconv.FormatBool(options.Annotations))
+	bodyWriter.WriteField("positions", strconv.FormatBool(options.ExtractPositions))
+
+}
+
+func writeFile(bodyWriter *multipart.Writer, file string) {
+
+	fileWriter, err := bodyWriter.CreateFormFile("file", file)
+
+	dat, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	fileWriter.Write(dat)
+
+}
+
+// postRequest posts the request form and streams the response body to the
+// output file, the response is returned once its body is written
+func postRequest(host string, contentType string, bodyBuf *bytes.Buffer, options SearchRequestOptions) *http.Response {
+
 	resp, err := http.Post(host, contentType, bodyBuf)
 
 	if err != nil || resp.StatusCode == 502 {
@@ -89,6 +168,12 @@ func NewSearchRequest(options SearchRequestOptions) {
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode == 400 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		fmt.Printf("Invalid search request : %s", msg)
+		os.Exit(1)
+	}
+
 	out := os.Stdout
 	if options.OutputFile != "stdout" {
 		out, err = os.Create(options.OutputFile)
@@ -97,21 +182,20 @@ func NewSearchRequest(options SearchRequestOptions) {
 		}
 	}
 
-	reader := bufio.NewReader(resp.Body)
-	runeSep := byte('\n')
-	if options.OutFormat == "json" {
-		runeSep = byte(',')
+	// results are streamed as they come
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		log.Fatal(err.Error())
 	}
-	for {
-		chunk, err := reader.ReadBytes(runeSep)
-
-		if err == io.EOF {
-			fmt.Fprint(out, string(chunk))
-			break
-		} else {
-			fmt.Fprint(out, string(chunk))
-		}
 
+	// the trailer is only read once the body is consumed
+	if resp.Trailer.Get(search.TIMEOUT_TRAILER) == "true" {
+		fmt.Fprintln(os.Stderr, "Search timed out, results are partial")
 	}
+	if searchErr := resp.Trailer.Get(search.ERROR_TRAILER); searchErr != "" {
+		fmt.Fprintf(os.Stderr, "Search error, results are partial : %s\n", searchErr)
+		os.Exit(1)
+	}
+
+	return resp
 
 }